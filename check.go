@@ -0,0 +1,166 @@
+package lazyenv
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkValue checks that the value just set in fieldValue satisfies the constraints of the tag of its field.
+// The "min" and "max" tags set an inclusive range for numbers. The bounds are read like the field itself,
+// so a time.Duration accepts min:"1s" and an integer tagged with size:"true" accepts max:"10MB".
+// The "oneof" tag lists the values a string can have separated by spaces, and "oneof_ci" does the same ignoring case.
+func (f *filler) checkValue(fieldValue reflect.Value, tag reflect.StructTag) error {
+	for fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	if fieldValue.Kind() == reflect.String {
+		if err := checkOneOf(fieldValue.String(), tag); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{"min", "max"} {
+		bound, ok := tag.Lookup(name)
+		if !ok || !isNumber(fieldValue.Kind()) {
+			continue
+		}
+		boundValue := reflect.New(fieldValue.Type()).Elem()
+		if err := f.setFieldValue(boundValue, bound, tag); err != nil {
+			return fmt.Errorf("invalid %s tag %q: %w", name, bound, err)
+		}
+		order := compareNumbers(fieldValue, boundValue)
+		if name == "min" && order < 0 {
+			return fmt.Errorf("%v is less than the minimum %s", fieldValue, bound)
+		}
+		if name == "max" && order > 0 {
+			return fmt.Errorf("%v is greater than the maximum %s", fieldValue, bound)
+		}
+	}
+	return nil
+}
+
+// checkOneOf checks that value is one of the values listed in the "oneof" or "oneof_ci" tag.
+func checkOneOf(value string, tag reflect.StructTag) error {
+	options, ok := tag.Lookup("oneof")
+	equal := func(a, b string) bool { return a == b }
+	if !ok {
+		if options, ok = tag.Lookup("oneof_ci"); !ok {
+			return nil
+		}
+		equal = strings.EqualFold
+	}
+	allowed := strings.Fields(options)
+	for _, option := range allowed {
+		if equal(value, option) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+// isNumber reports whether kind is an integer or a floating point number.
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// compareNumbers returns -1, 0 or 1 when a is less than, equal to or greater than b.
+// a and b must be numbers of the same type.
+func compareNumbers(a, b reflect.Value) int {
+	switch {
+	case a.CanInt():
+		return cmp.Compare(a.Int(), b.Int())
+	case a.CanUint():
+		return cmp.Compare(a.Uint(), b.Uint())
+	default:
+		return cmp.Compare(a.Float(), b.Float())
+	}
+}
+
+// checkGroups checks that exactly one field of each group of the struct type t is set. The fields of a group
+// share the same "group" tag, like an URL and the struct with the parts of the URL tagged with group:"dbsource".
+// A field is set when any of its variables is set, and a nested struct when any variable of its fields is.
+// The fields in preset, which already had a value before the fill, are set too.
+func (f *filler) checkGroups(t reflect.Type, prefix, path string, preset map[string]bool) {
+	var groups []string
+	members := map[string][]string{}
+	set := map[string][]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		group := field.Tag.Get("group")
+		if group == "" || skipField(field) {
+			continue
+		}
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], field.Name)
+
+		if preset[field.Name] || f.isFieldSet(field, prefix) {
+			set[group] = append(set[group], field.Name)
+		}
+	}
+
+	for _, group := range groups {
+		switch len(set[group]) {
+		case 1:
+			continue
+		case 0:
+			f.failAs(ErrValidate, strings.TrimSuffix(path, "."), "", "", reflect.Struct,
+				fmt.Errorf("exactly one of %s must be set for group %s, none is", strings.Join(members[group], ", "), group))
+		default:
+			f.failAs(ErrValidate, strings.TrimSuffix(path, "."), "", "", reflect.Struct,
+				fmt.Errorf("exactly one of %s must be set for group %s, got %s", strings.Join(members[group], ", "), group, strings.Join(set[group], " and ")))
+		}
+	}
+}
+
+// isFieldSet reports whether any of the variables of field is set, including the variables of its fields
+// for a nested struct, and the indexed variables for a slice of structs.
+func (f *filler) isFieldSet(field reflect.StructField, prefix string) bool {
+	if isNested(field.Type) {
+		return f.hasStructKey(field.Type, nestedPrefix(field, prefix, f.nestedSeparator()))
+	}
+	var keys []string
+	for _, name := range fieldEnvNames(field) {
+		keys = append(keys, prefix+name)
+		if f.files {
+			keys = append(keys, prefix+name+"_FILE")
+		}
+	}
+	if f.hasAnyKey(keys) {
+		return true
+	}
+	if !isIndexedSlice(field.Type) {
+		return false
+	}
+	length, overKey := f.indexedLen(nestedPrefix(field, prefix, f.nestedSeparator()), field.Type.Elem())
+	return length > 0 || overKey != ""
+}
+
+// presetFields returns the names of the fields of the struct v with a "group" tag that already have a value,
+// when the fill keeps the values set beforehand, like in FillJSON and FillMissing.
+func (f *filler) presetFields(v reflect.Value) map[string]bool {
+	if !f.prefilled && !f.missingOnly {
+		return nil
+	}
+	preset := map[string]bool{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("group") != "" && !skipField(field) && !v.Field(i).IsZero() {
+			preset[field.Name] = true
+		}
+	}
+	return preset
+}
@@ -0,0 +1,204 @@
+package lazyenv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFillMinMax(t *testing.T) {
+	type Config struct {
+		Port    int           `min:"1" max:"65535"`
+		Workers uint8         `min:"1"`
+		Ratio   float64       `min:"0" max:"1"`
+		Timeout time.Duration `min:"1s" max:"1m"`
+		Limit   *int64        `max:"10MB" size:"true"`
+		Retries int           `max:"5" default:"3"`
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		paths []string
+	}{
+		{
+			name: "within range",
+			env:  map[string]string{"PORT": "65535", "WORKERS": "1", "RATIO": "0.5", "TIMEOUT": "1m", "LIMIT": "10MB"},
+		},
+		{
+			name:  "below minimum",
+			env:   map[string]string{"PORT": "0", "WORKERS": "0", "RATIO": "-0.1", "TIMEOUT": "500ms"},
+			paths: []string{"Port", "Workers", "Ratio", "Timeout"},
+		},
+		{
+			name:  "above maximum",
+			env:   map[string]string{"PORT": "70000", "RATIO": "1.5", "TIMEOUT": "2m", "LIMIT": "11MB", "RETRIES": "6"},
+			paths: []string{"Port", "Ratio", "Timeout", "Limit", "Retries"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			err := FillWithOptions(&config, Options{Lookup: mapLookup(tt.env)})
+
+			var paths []string
+			var errs Errors
+			if errors.As(err, &errs) {
+				for _, fieldErr := range errs {
+					paths = append(paths, fieldErr.Path)
+				}
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("FillWithOptions() error = %v; expected errors for %v", err, tt.paths)
+			}
+		})
+	}
+}
+
+func TestFillMinMaxInvalidTag(t *testing.T) {
+	var config struct {
+		Port int `min:"one"`
+	}
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"PORT": "80"})})
+	if err == nil {
+		t.Errorf("FillWithOptions() error = nil; expected an error for the invalid min tag")
+	}
+}
+
+func TestFillOneOf(t *testing.T) {
+	type Config struct {
+		LogLevel string  `oneof:"debug info warn error"`
+		Format   string  `oneof_ci:"json text"`
+		Mode     *string `oneof:"fast safe" default:"safe"`
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		paths []string
+	}{
+		{
+			name: "allowed",
+			env:  map[string]string{"LOG_LEVEL": "warn", "FORMAT": "JSON"},
+		},
+		{
+			name:  "not allowed",
+			env:   map[string]string{"LOG_LEVEL": "WARN", "FORMAT": "xml", "MODE": "slow"},
+			paths: []string{"LogLevel", "Format", "Mode"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			err := FillWithOptions(&config, Options{Lookup: mapLookup(tt.env)})
+
+			var paths []string
+			var errs Errors
+			if errors.As(err, &errs) {
+				for _, fieldErr := range errs {
+					paths = append(paths, fieldErr.Path)
+				}
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("FillWithOptions() error = %v; expected errors for %v", err, tt.paths)
+			}
+		})
+	}
+}
+
+func TestFillOneOfError(t *testing.T) {
+	var config struct {
+		LogLevel string `oneof:"debug info"`
+	}
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"LOG_LEVEL": "trace"})})
+
+	expect := `lazyenv: LogLevel (LOG_LEVEL): "trace" is not one of debug, info`
+	if err == nil || err.Error() != expect {
+		t.Errorf("FillWithOptions() error = %v; expected %s", err, expect)
+	}
+}
+
+func TestFillGroup(t *testing.T) {
+	type Replica struct {
+		Host string
+	}
+	type DBParts struct {
+		Host     string
+		Name     string
+		Replicas []Replica
+	}
+	type Config struct {
+		DatabaseURL string   `group:"dbsource"`
+		DB          *DBParts `group:"dbsource"`
+		Port        int
+	}
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		expect string
+	}{
+		{
+			name: "url",
+			env:  map[string]string{"DATABASE_URL": "postgres://db/app"},
+		},
+		{
+			name: "parts",
+			env:  map[string]string{"DB_HOST": "db", "DB_NAME": "app"},
+		},
+		{
+			name: "indexed parts",
+			env:  map[string]string{"DB_REPLICAS_0_HOST": "db2"},
+		},
+		{
+			name:   "none",
+			env:    map[string]string{"PORT": "80"},
+			expect: "lazyenv: exactly one of DatabaseURL, DB must be set for group dbsource, none is",
+		},
+		{
+			name:   "both",
+			env:    map[string]string{"DATABASE_URL": "postgres://db/app", "DB_HOST": "db"},
+			expect: "lazyenv: exactly one of DatabaseURL, DB must be set for group dbsource, got DatabaseURL and DB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			err := FillWithOptions(&config, Options{Lookup: mapLookup(tt.env)})
+			if tt.expect == "" && err != nil {
+				t.Errorf("FillWithOptions() error = %v; expected nil", err)
+			}
+			if tt.expect != "" && (err == nil || err.Error() != tt.expect) {
+				t.Errorf("FillWithOptions() error = %v; expected %s", err, tt.expect)
+			}
+		})
+	}
+}
+
+func TestFillGroupPrefilled(t *testing.T) {
+	type DBParts struct {
+		Host string
+	}
+	type Config struct {
+		URL string  `json:"url" group:"db"`
+		DB  DBParts `json:"db" group:"db"`
+	}
+
+	t.Setenv("CONFIG_JSON", `{"url":"postgres://db/app"}`)
+	var config Config
+	if err := FillJSON(&config, "CONFIG_JSON"); err != nil {
+		t.Errorf("FillJSON() error = %v; expected the URL from the JSON document to count as set", err)
+	}
+
+	t.Setenv("DB_HOST", "db")
+	config = Config{}
+	err := FillJSON(&config, "CONFIG_JSON")
+	expect := "lazyenv: exactly one of URL, DB must be set for group db, got URL and DB"
+	if err == nil || err.Error() != expect {
+		t.Errorf("FillJSON() error = %v; expected %s", err, expect)
+	}
+}
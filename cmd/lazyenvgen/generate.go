@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unsupportedTags are the tags that change how Fill reads a field and that the generated code doesn't implement.
+var unsupportedTags = []string{
+	"required", "encoding", "layout", "kvsep", "csv", "size", "char", "min", "max", "oneof", "oneof_ci", "emptybool", "lines", "expand", "truthy", "omitempty", "transform", "percent", "deprecated", "trimprefix", "trimsuffix", "iso8601", "group",
+}
+
+// generator writes the fill functions of the types of a package.
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string // Name of the imported packages by path
+}
+
+// generate returns the formatted source of the fill functions of typeNames, which must be struct types of pkg.
+func generate(pkg *types.Package, typeNames []string) ([]byte, error) {
+	g := &generator{
+		pkg: pkg,
+		imports: map[string]string{
+			"encoding": "encoding",
+			"os":       "os",
+			"slices":   "slices",
+			"strconv":  "strconv",
+			"strings":  "strings",
+		},
+	}
+
+	var body bytes.Buffer
+	for _, name := range typeNames {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Path())
+		}
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct type", name)
+		}
+		fmt.Fprintf(&body, "\n// Fill%s fills cfg from the environment like lazyenv.Fill, without reflection.\n", name)
+		fmt.Fprintf(&body, "func Fill%s(cfg *%s) {\n", name, name)
+		body.WriteString("\tresolved := map[string]string{}\n")
+		if err := g.writeFields(&body, "cfg", st, "", name); err != nil {
+			return nil, err
+		}
+		body.WriteString("}\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by lazyenvgen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg.Name())
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if name := g.imports[path]; name != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&src, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+	}
+	src.WriteString(")\n")
+	src.Write(body.Bytes())
+	src.WriteString(helpers)
+	return format.Source(src.Bytes())
+}
+
+// writeFields writes the code that fills the fields of st, a struct accessed with the expression target.
+// prefix is prepended to the keys and path is the dotted path of the struct, used in the errors.
+func (g *generator) writeFields(w *bytes.Buffer, target string, st *types.Struct, prefix, path string) error {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		if skipField(field, tag) {
+			continue
+		}
+		fieldPath := path + "." + field.Name()
+		for _, name := range unsupportedTags {
+			if _, ok := tag.Lookup(name); ok {
+				return fmt.Errorf("%s: the %s tag is not supported", fieldPath, name)
+			}
+		}
+		access := target + "." + field.Name()
+
+		if isNested(field.Type()) {
+			fieldType := field.Type()
+			st := structType(fieldType)
+			ptr, isPtr := fieldType.Underlying().(*types.Pointer)
+			if !isPtr {
+				if err := g.writeFields(w, access, st, nestedPrefix(field, tag, prefix), fieldPath); err != nil {
+					return err
+				}
+				continue
+			}
+			// Like Fill, a nil pointer is only allocated when any of the variables of the struct is set.
+			// Slices of structs are not supported, so there are no indexed variables to look for
+			var keys []string
+			for _, key := range structKeys(st, nestedPrefix(field, tag, prefix)) {
+				keys = append(keys, fmt.Sprintf("%q", key))
+			}
+			fmt.Fprintf(w, "\tif %s == nil && lazyenvAnySet(%s) {\n\t\t%s = new(%s)\n\t}\n", access, strings.Join(keys, ", "), access, g.typeString(ptr.Elem()))
+			fmt.Fprintf(w, "\tif %s != nil {\n", access)
+			if err := g.writeFields(w, access, st, nestedPrefix(field, tag, prefix), fieldPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "\t}\n")
+			continue
+		}
+
+		expr, err := g.convert(field.Type(), "value", tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fieldPath, err)
+		}
+		var keys []string
+		for _, name := range fieldEnvNames(field.Name(), tag) {
+			keys = append(keys, fmt.Sprintf("%q", prefix+name))
+		}
+		fallback, hasFallback := tag.Lookup("default")
+		if strings.Contains(fallback, "{{") {
+			return fmt.Errorf("%s: template defaults are not supported", fieldPath)
+		}
+		interpolate := tag.Get("interpolate") == "true"
+		fmt.Fprintf(w, "\tif value, ok := lazyenvLookup(resolved, %t, %q, %t, %s); ok {\n", interpolate, fallback, hasFallback, strings.Join(keys, ", "))
+		fmt.Fprintf(w, "\t\tif v, err := %s; err == nil {\n\t\t\t%s = v\n\t\t}\n\t}\n", expr, access)
+	}
+	return nil
+}
+
+// convert returns an expression that converts the string expression in to the type t, returning a value and an error.
+func (g *generator) convert(t types.Type, in string, tag reflect.StructTag) (string, error) {
+	t = types.Unalias(t)
+	if tag.Get("trim") == "true" {
+		in = "strings.TrimSpace(" + in + ")"
+	}
+
+	switch {
+	case isNamed(t, "time", "Duration"):
+		g.imports["time"] = "time"
+		return "time.ParseDuration(" + in + ")", nil
+	case isNamed(t, "time", "Time"), isNamed(t, "net/url", "URL"), isNamed(t, "net/url", "Values"), isNamed(t, "net", "IPNet"):
+		return "", fmt.Errorf("unsupported type %s", t)
+	}
+	if _, ok := t.Underlying().(*types.Pointer); !ok && implementsText(t) {
+		if _, ok := t.Underlying().(*types.Struct); ok && hasFields(t) {
+			return "", fmt.Errorf("unsupported type %s, a struct with fields that implements encoding.TextUnmarshaler", t)
+		}
+		return fmt.Sprintf("lazyenvText[%s](%s)", g.typeString(t), in), nil
+	}
+	if _, ok := t.Underlying().(*types.Pointer); !ok && implementsFlag(t) {
+		return "", fmt.Errorf("unsupported type %s, which implements flag.Value", t)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		if _, ok := t.(*types.Named); ok {
+			break
+		}
+		elem, err := g.convert(u.Elem(), in, tag)
+		if err != nil {
+			return "", err
+		}
+		return "lazyenvPointer(" + elem + ")", nil
+	case *types.Slice:
+		if basic, ok := u.Elem().Underlying().(*types.Basic); ok && basic.Kind() == types.Uint8 {
+			return fmt.Sprintf("lazyenvBytes[%s](%s)", g.typeString(t), in), nil
+		}
+		if isNested(u.Elem()) {
+			break
+		}
+		elem, err := g.convert(u.Elem(), "value", tag)
+		if err != nil {
+			return "", err
+		}
+		sep := tag.Get("sep")
+		if sep == "" {
+			sep = ","
+		}
+		if sep == "\n" {
+			return "", fmt.Errorf("the %q separator is not supported", sep)
+		}
+		return fmt.Sprintf("lazyenvSlice[%s](%s, %q, func(value string) (%s, error) { return %s })",
+			g.typeString(t), in, sep, g.typeString(u.Elem()), elem), nil
+	case *types.Basic:
+		name := g.typeString(t)
+		switch u.Kind() {
+		case types.String:
+			return fmt.Sprintf("lazyenvString[%s](%s)", name, in), nil
+		case types.Bool:
+			return fmt.Sprintf("lazyenvBool[%s](%s)", name, in), nil
+		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
+			return fmt.Sprintf("lazyenvInt[%s](%s, %d)", name, in, bits(u)), nil
+		case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+			return fmt.Sprintf("lazyenvUint[%s](%s, %d)", name, in, bits(u)), nil
+		case types.Float32, types.Float64:
+			return fmt.Sprintf("lazyenvFloat[%s](%s, %d)", name, in, bits(u)), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// typeString returns t as written in the generated package, recording the packages to import.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == g.pkg {
+			return ""
+		}
+		g.imports[pkg.Path()] = pkg.Name()
+		return pkg.Name()
+	})
+}
+
+// bits returns the size of a number type for strconv. int and uint are 0, the size of the platform.
+func bits(t *types.Basic) int {
+	switch t.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int64, types.Uint64, types.Float64:
+		return 64
+	}
+	return 0
+}
+
+// isNamed reports whether t is the type name of the package with the path pkgPath.
+func isNamed(t types.Type, pkgPath, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath && obj.Name() == name
+}
+
+// textUnmarshaler is the encoding.TextUnmarshaler interface.
+var textUnmarshaler = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "UnmarshalText", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(0, nil, "text", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(types.NewParam(0, nil, "", types.Universe.Lookup("error").Type())),
+		false)),
+}, nil).Complete()
+
+// implementsText reports whether a pointer to t implements encoding.TextUnmarshaler.
+func implementsText(t types.Type) bool {
+	return types.Implements(types.NewPointer(t), textUnmarshaler)
+}
+
+// flagValue is the flag.Value interface.
+var flagValue = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "String", types.NewSignatureType(nil, nil, nil, nil,
+		types.NewTuple(types.NewParam(0, nil, "", types.Typ[types.String])),
+		false)),
+	types.NewFunc(0, nil, "Set", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewParam(0, nil, "value", types.Typ[types.String])),
+		types.NewTuple(types.NewParam(0, nil, "", types.Universe.Lookup("error").Type())),
+		false)),
+}, nil).Complete()
+
+// implementsFlag reports whether a pointer to t implements flag.Value.
+func implementsFlag(t types.Type) bool {
+	return types.Implements(types.NewPointer(t), flagValue)
+}
+
+// isNested reports whether t is a struct, or a pointer to a struct, whose fields are filled recursively.
+// It mirrors isNested in the lazyenv package.
+func isNested(t types.Type) bool {
+	t = types.Unalias(t)
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = types.Unalias(ptr.Elem())
+	}
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return false
+	}
+	return !isNamed(t, "time", "Time") && !isNamed(t, "net/url", "URL") && !isNamed(t, "net", "IPNet") && !implementsText(t) && !implementsFlag(t)
+}
+
+// hasFields reports whether the struct type t has fields that Fill would read.
+func hasFields(t types.Type) bool {
+	st := t.Underlying().(*types.Struct)
+	for i := 0; i < st.NumFields(); i++ {
+		if !skipField(st.Field(i), reflect.StructTag(st.Tag(i))) {
+			return true
+		}
+	}
+	return false
+}
+
+// helpers are written at the end of the generated file. They implement the conversions of Fill.
+const helpers = `
+// lazyenvLookup returns the value of the first of keys that is set, or the contents of the file named by the
+// first of keys with a _FILE suffix that is set, or fallback if none is and hasFallback is true.
+// The ${NAME} references in the value are interpolated if interpolate is true, and the result is added to resolved.
+func lazyenvLookup(resolved map[string]string, interpolate bool, fallback string, hasFallback bool, keys ...string) (string, bool) {
+	key, value, ok := keys[0], fallback, hasFallback
+	var visiting []string
+	for _, name := range keys {
+		if envValue, found := os.LookupEnv(name); found {
+			key, value, ok = name, envValue, true
+			visiting = []string{name}
+			break
+		}
+	}
+	if len(visiting) == 0 {
+		for _, name := range keys {
+			if path, found := os.LookupEnv(name + "_FILE"); found {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return "", false
+				}
+				key, value, ok = name+"_FILE", strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r"), true
+				visiting = []string{key}
+				break
+			}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	if interpolate {
+		if value, ok = lazyenvInterpolate(resolved, value, visiting); !ok {
+			return "", false
+		}
+	}
+	resolved[key] = value
+	return value, true
+}
+
+// lazyenvAnySet reports whether any of keys is set.
+func lazyenvAnySet(keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := os.LookupEnv(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lazyenvInterpolate replaces the ${NAME} references in value with the values of the variables,
+// or with the resolved values of the fields filled before if they are not set.
+// It returns false if a variable references itself.
+func lazyenvInterpolate(resolved map[string]string, value string, visiting []string) (string, bool) {
+	var result strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		if start > 0 && value[start-1] == '$' {
+			result.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := value[start+2 : start+end]
+		result.WriteString(value[:start])
+		value = value[start+end+1:]
+
+		if slices.Contains(visiting, name) {
+			return "", false
+		}
+		ref, ok := os.LookupEnv(name)
+		if !ok {
+			result.WriteString(resolved[name])
+			continue
+		}
+		if ref, ok = lazyenvInterpolate(resolved, ref, append(visiting, name)); !ok {
+			return "", false
+		}
+		result.WriteString(ref)
+	}
+	result.WriteString(value)
+	return result.String(), true
+}
+
+func lazyenvString[T ~string](value string) (T, error) {
+	return T(value), nil
+}
+
+func lazyenvBytes[T ~[]byte](value string) (T, error) {
+	return T(value), nil
+}
+
+func lazyenvBool[T ~bool](value string) (T, error) {
+	switch strings.ToLower(value) {
+	case "", "yes", "on", "enabled":
+		return true, nil
+	case "no", "off", "disabled":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	return T(b), err
+}
+
+// lazyenvIntBase returns the value and base to parse an integer with.
+func lazyenvIntBase(value string) (string, int) {
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xXoObB", rune(digits[1])) {
+		return value, 0
+	}
+	return strings.ReplaceAll(value, "_", ""), 10
+}
+
+func lazyenvInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](value string, bits int) (T, error) {
+	value, base := lazyenvIntBase(value)
+	n, err := strconv.ParseInt(value, base, bits)
+	return T(n), err
+}
+
+func lazyenvUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](value string, bits int) (T, error) {
+	value, base := lazyenvIntBase(value)
+	n, err := strconv.ParseUint(value, base, bits)
+	return T(n), err
+}
+
+func lazyenvFloat[T ~float32 | ~float64](value string, bits int) (T, error) {
+	f, err := strconv.ParseFloat(value, bits)
+	return T(f), err
+}
+
+func lazyenvText[T any, P interface {
+	*T
+	encoding.TextUnmarshaler
+}](value string) (T, error) {
+	var v T
+	err := P(&v).UnmarshalText([]byte(value))
+	return v, err
+}
+
+func lazyenvPointer[T any](value T, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func lazyenvSlice[S ~[]E, E any](value, sep string, parse func(string) (E, error)) (S, error) {
+	if value == "" {
+		return S{}, nil
+	}
+	values := strings.Split(value, sep)
+	slice := make(S, len(values))
+	for i, value := range values {
+		var err error
+		if slice[i], err = parse(value); err != nil {
+			return nil, err
+		}
+	}
+	return slice, nil
+}
+`
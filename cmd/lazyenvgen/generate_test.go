@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	pkg, err := loadPackage("./internal/example")
+	if err != nil {
+		t.Fatalf("loadPackage() error = %v", err)
+	}
+	src, err := generate(pkg.Types, []string{"Config", "Flags"})
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	expect, err := os.ReadFile("internal/example/lazyenv_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, expect) {
+		t.Errorf("generate() doesn't match internal/example/lazyenv_gen.go, run go generate ./...")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		expect string
+	}{
+		{
+			name:   "unsupported type",
+			src:    "type Config struct { Limits map[string]int }",
+			expect: "Config.Limits: unsupported type map[string]int",
+		},
+		{
+			name:   "unsupported tag",
+			src:    "type Config struct { DB struct { Port int `min:\"1\"` } }",
+			expect: "Config.DB.Port: the min tag is not supported",
+		},
+		{
+			name:   "flag value",
+			src:    "type Mode int\nfunc (m *Mode) Set(string) error { return nil }\nfunc (m *Mode) String() string { return \"\" }\ntype Config struct { Mode Mode }",
+			expect: "Config.Mode: unsupported type p.Mode, which implements flag.Value",
+		},
+		{
+			name:   "indexed slice",
+			src:    "type Server struct { Host string }\ntype Config struct { DB *struct { Servers []Server } }",
+			expect: "Config.DB.Servers: unsupported type []p.Server",
+		},
+		{
+			name:   "template default",
+			src:    "type Config struct { Node string `default:\"{{.Hostname}}-node\"` }",
+			expect: "Config.Node: template defaults are not supported",
+		},
+		{
+			name:   "not a struct",
+			src:    "type Config int",
+			expect: "Config is not a struct type",
+		},
+		{
+			name:   "missing type",
+			src:    "type Settings struct{}",
+			expect: "type Config not found in package p",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "p.go", "package p\n"+tt.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg, err := new(types.Config).Check("p", fset, []*ast.File{file}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = generate(pkg, []string{"Config"})
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("generate() error = %v; expected %q", err, tt.expect)
+			}
+		})
+	}
+}
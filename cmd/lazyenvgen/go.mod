@@ -0,0 +1,15 @@
+module golazy.dev/lazyenv/cmd/lazyenvgen
+
+go 1.23.1
+
+require (
+	golang.org/x/tools v0.36.0
+	golazy.dev/lazyenv v0.0.0-20261014062346-32165277d1e1
+)
+
+require (
+	golang.org/x/mod v0.27.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/term v0.24.0 // indirect
+)
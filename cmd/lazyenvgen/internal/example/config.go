@@ -0,0 +1,61 @@
+// Package example has the configuration used to test the code generated by lazyenvgen against lazyenv.Fill.
+package example
+
+import (
+	"net"
+	"time"
+)
+
+//go:generate go run golazy.dev/lazyenv/cmd/lazyenvgen -type Config,Flags
+
+type Level int
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		*l = 2
+	}
+	return nil
+}
+
+type Base struct {
+	Host    string `default:"localhost"`
+	Name    string `default:"app"`
+	Version string `json:"app-version"`
+}
+
+type Database struct {
+	Host     string `default:"localhost"`
+	Port     uint16 `default:"5432"`
+	Password string `secret:"true"`
+}
+
+type Config struct {
+	Base
+	URL      string        `env:"APP_URL,URL" interpolate:"true" default:"http://${HOST}:${PORT}"`
+	Debug    bool          `default:"yes"`
+	Workers  int           `default:"1_000"`
+	Mask     uint32        `default:"0xff"`
+	Ratio    float32       `default:"0.5"`
+	Timeout  time.Duration `default:"30s"`
+	Level    Level
+	Bind     net.IP
+	Hosts    []string `trim:"true"`
+	Ports    []int    `sep:";"`
+	Token    []byte
+	Optional *string
+	Limit    *int64
+	DB       Database `env:"DATABASE"`
+	Cache    *Database
+	Ignored  string `env:"-"`
+	internal string
+}
+
+type Flags struct {
+	Beta     bool
+	Features []string
+}
@@ -0,0 +1,95 @@
+package example
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golazy.dev/lazyenv"
+)
+
+func TestFillConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "values",
+			env: map[string]string{
+				"NAME":          "api",
+				"APP_VERSION":   "1.2.3",
+				"HOST":          "example.com",
+				"PORT":          "8080",
+				"DEBUG":         "off",
+				"WORKERS":       "0x10",
+				"RATIO":         "0.25",
+				"TIMEOUT":       "1m",
+				"LEVEL":         "info",
+				"BIND":          "10.0.0.1",
+				"HOSTS":         " a , b ",
+				"PORTS":         "80;443",
+				"TOKEN":         "a${HOST}c",
+				"OPTIONAL":      "",
+				"LIMIT":         "-1",
+				"DATABASE_HOST": "db.example.com",
+				"CACHE_PORT":    "6379",
+				"IGNORED":       "ignored",
+				"BETA":          "",
+				"FEATURES":      "",
+			},
+		},
+		{
+			name: "alternate names and invalid values",
+			env: map[string]string{
+				"URL":           "https://example.com",
+				"WORKERS":       "many",
+				"MASK":          "-1",
+				"PORTS":         "80;http",
+				"DATABASE_PORT": "70000",
+				"FEATURES":      "a,b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var generated, reflected Config
+			FillConfig(&generated)
+			lazyenv.Fill(&reflected)
+			if !reflect.DeepEqual(generated, reflected) {
+				t.Errorf("FillConfig() = %+v; expected %+v", generated, reflected)
+			}
+
+			var generatedFlags, reflectedFlags Flags
+			FillFlags(&generatedFlags)
+			lazyenv.Fill(&reflectedFlags)
+			if !reflect.DeepEqual(generatedFlags, reflectedFlags) {
+				t.Errorf("FillFlags() = %+v; expected %+v", generatedFlags, reflectedFlags)
+			}
+		})
+	}
+}
+
+func TestFillConfigFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("from_file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TOKEN_FILE", path)
+	t.Setenv("HOST_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	var generated, reflected Config
+	FillConfig(&generated)
+	lazyenv.Fill(&reflected)
+	if !reflect.DeepEqual(generated, reflected) {
+		t.Errorf("FillConfig() = %+v; expected %+v", generated, reflected)
+	}
+}
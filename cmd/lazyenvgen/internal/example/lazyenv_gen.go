@@ -0,0 +1,312 @@
+// Code generated by lazyenvgen; DO NOT EDIT.
+
+package example
+
+import (
+	"encoding"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FillConfig fills cfg from the environment like lazyenv.Fill, without reflection.
+func FillConfig(cfg *Config) {
+	resolved := map[string]string{}
+	if value, ok := lazyenvLookup(resolved, false, "localhost", true, "HOST"); ok {
+		if v, err := lazyenvString[string](value); err == nil {
+			cfg.Base.Host = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "app", true, "NAME"); ok {
+		if v, err := lazyenvString[string](value); err == nil {
+			cfg.Base.Name = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "APP_VERSION"); ok {
+		if v, err := lazyenvString[string](value); err == nil {
+			cfg.Base.Version = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, true, "http://${HOST}:${PORT}", true, "APP_URL", "URL"); ok {
+		if v, err := lazyenvString[string](value); err == nil {
+			cfg.URL = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "yes", true, "DEBUG"); ok {
+		if v, err := lazyenvBool[bool](value); err == nil {
+			cfg.Debug = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "1_000", true, "WORKERS"); ok {
+		if v, err := lazyenvInt[int](value, 0); err == nil {
+			cfg.Workers = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "0xff", true, "MASK"); ok {
+		if v, err := lazyenvUint[uint32](value, 32); err == nil {
+			cfg.Mask = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "0.5", true, "RATIO"); ok {
+		if v, err := lazyenvFloat[float32](value, 32); err == nil {
+			cfg.Ratio = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "30s", true, "TIMEOUT"); ok {
+		if v, err := time.ParseDuration(value); err == nil {
+			cfg.Timeout = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "LEVEL"); ok {
+		if v, err := lazyenvText[Level](value); err == nil {
+			cfg.Level = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "BIND"); ok {
+		if v, err := lazyenvText[net.IP](value); err == nil {
+			cfg.Bind = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "HOSTS"); ok {
+		if v, err := lazyenvSlice[[]string](strings.TrimSpace(value), ",", func(value string) (string, error) { return lazyenvString[string](strings.TrimSpace(value)) }); err == nil {
+			cfg.Hosts = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "PORTS"); ok {
+		if v, err := lazyenvSlice[[]int](value, ";", func(value string) (int, error) { return lazyenvInt[int](value, 0) }); err == nil {
+			cfg.Ports = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "TOKEN"); ok {
+		if v, err := lazyenvBytes[[]byte](value); err == nil {
+			cfg.Token = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "OPTIONAL"); ok {
+		if v, err := lazyenvPointer(lazyenvString[string](value)); err == nil {
+			cfg.Optional = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "LIMIT"); ok {
+		if v, err := lazyenvPointer(lazyenvInt[int64](value, 64)); err == nil {
+			cfg.Limit = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "localhost", true, "DATABASE_HOST"); ok {
+		if v, err := lazyenvString[string](value); err == nil {
+			cfg.DB.Host = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "5432", true, "DATABASE_PORT"); ok {
+		if v, err := lazyenvUint[uint16](value, 16); err == nil {
+			cfg.DB.Port = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "DATABASE_PASSWORD"); ok {
+		if v, err := lazyenvString[string](value); err == nil {
+			cfg.DB.Password = v
+		}
+	}
+	if cfg.Cache == nil && lazyenvAnySet("CACHE_HOST", "CACHE_HOST_FILE", "CACHE_PORT", "CACHE_PORT_FILE", "CACHE_PASSWORD", "CACHE_PASSWORD_FILE") {
+		cfg.Cache = new(Database)
+	}
+	if cfg.Cache != nil {
+		if value, ok := lazyenvLookup(resolved, false, "localhost", true, "CACHE_HOST"); ok {
+			if v, err := lazyenvString[string](value); err == nil {
+				cfg.Cache.Host = v
+			}
+		}
+		if value, ok := lazyenvLookup(resolved, false, "5432", true, "CACHE_PORT"); ok {
+			if v, err := lazyenvUint[uint16](value, 16); err == nil {
+				cfg.Cache.Port = v
+			}
+		}
+		if value, ok := lazyenvLookup(resolved, false, "", false, "CACHE_PASSWORD"); ok {
+			if v, err := lazyenvString[string](value); err == nil {
+				cfg.Cache.Password = v
+			}
+		}
+	}
+}
+
+// FillFlags fills cfg from the environment like lazyenv.Fill, without reflection.
+func FillFlags(cfg *Flags) {
+	resolved := map[string]string{}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "BETA"); ok {
+		if v, err := lazyenvBool[bool](value); err == nil {
+			cfg.Beta = v
+		}
+	}
+	if value, ok := lazyenvLookup(resolved, false, "", false, "FEATURES"); ok {
+		if v, err := lazyenvSlice[[]string](value, ",", func(value string) (string, error) { return lazyenvString[string](value) }); err == nil {
+			cfg.Features = v
+		}
+	}
+}
+
+// lazyenvLookup returns the value of the first of keys that is set, or the contents of the file named by the
+// first of keys with a _FILE suffix that is set, or fallback if none is and hasFallback is true.
+// The ${NAME} references in the value are interpolated if interpolate is true, and the result is added to resolved.
+func lazyenvLookup(resolved map[string]string, interpolate bool, fallback string, hasFallback bool, keys ...string) (string, bool) {
+	key, value, ok := keys[0], fallback, hasFallback
+	var visiting []string
+	for _, name := range keys {
+		if envValue, found := os.LookupEnv(name); found {
+			key, value, ok = name, envValue, true
+			visiting = []string{name}
+			break
+		}
+	}
+	if len(visiting) == 0 {
+		for _, name := range keys {
+			if path, found := os.LookupEnv(name + "_FILE"); found {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return "", false
+				}
+				key, value, ok = name+"_FILE", strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r"), true
+				visiting = []string{key}
+				break
+			}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	if interpolate {
+		if value, ok = lazyenvInterpolate(resolved, value, visiting); !ok {
+			return "", false
+		}
+	}
+	resolved[key] = value
+	return value, true
+}
+
+// lazyenvAnySet reports whether any of keys is set.
+func lazyenvAnySet(keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := os.LookupEnv(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lazyenvInterpolate replaces the ${NAME} references in value with the values of the variables,
+// or with the resolved values of the fields filled before if they are not set.
+// It returns false if a variable references itself.
+func lazyenvInterpolate(resolved map[string]string, value string, visiting []string) (string, bool) {
+	var result strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		if start > 0 && value[start-1] == '$' {
+			result.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := value[start+2 : start+end]
+		result.WriteString(value[:start])
+		value = value[start+end+1:]
+
+		if slices.Contains(visiting, name) {
+			return "", false
+		}
+		ref, ok := os.LookupEnv(name)
+		if !ok {
+			result.WriteString(resolved[name])
+			continue
+		}
+		if ref, ok = lazyenvInterpolate(resolved, ref, append(visiting, name)); !ok {
+			return "", false
+		}
+		result.WriteString(ref)
+	}
+	result.WriteString(value)
+	return result.String(), true
+}
+
+func lazyenvString[T ~string](value string) (T, error) {
+	return T(value), nil
+}
+
+func lazyenvBytes[T ~[]byte](value string) (T, error) {
+	return T(value), nil
+}
+
+func lazyenvBool[T ~bool](value string) (T, error) {
+	switch strings.ToLower(value) {
+	case "", "yes", "on", "enabled":
+		return true, nil
+	case "no", "off", "disabled":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	return T(b), err
+}
+
+// lazyenvIntBase returns the value and base to parse an integer with.
+func lazyenvIntBase(value string) (string, int) {
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xXoObB", rune(digits[1])) {
+		return value, 0
+	}
+	return strings.ReplaceAll(value, "_", ""), 10
+}
+
+func lazyenvInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](value string, bits int) (T, error) {
+	value, base := lazyenvIntBase(value)
+	n, err := strconv.ParseInt(value, base, bits)
+	return T(n), err
+}
+
+func lazyenvUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](value string, bits int) (T, error) {
+	value, base := lazyenvIntBase(value)
+	n, err := strconv.ParseUint(value, base, bits)
+	return T(n), err
+}
+
+func lazyenvFloat[T ~float32 | ~float64](value string, bits int) (T, error) {
+	f, err := strconv.ParseFloat(value, bits)
+	return T(f), err
+}
+
+func lazyenvText[T any, P interface {
+	*T
+	encoding.TextUnmarshaler
+}](value string) (T, error) {
+	var v T
+	err := P(&v).UnmarshalText([]byte(value))
+	return v, err
+}
+
+func lazyenvPointer[T any](value T, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func lazyenvSlice[S ~[]E, E any](value, sep string, parse func(string) (E, error)) (S, error) {
+	if value == "" {
+		return S{}, nil
+	}
+	values := strings.Split(value, sep)
+	slice := make(S, len(values))
+	for i, value := range values {
+		var err error
+		if slice[i], err = parse(value); err != nil {
+			return nil, err
+		}
+	}
+	return slice, nil
+}
@@ -0,0 +1,95 @@
+// Lazyenvgen generates functions that fill structs from the environment like lazyenv.Fill, without reflection.
+//
+// For every type given with -type it writes a FillType(cfg *Type) function that reads the same variables as
+// lazyenv.Fill, with direct os.LookupEnv and strconv calls. It is meant to be used with go:generate:
+//
+//	//go:generate go run golazy.dev/lazyenv/cmd/lazyenvgen@latest -type Config
+//
+// The generated file also contains some helpers, so all the types of a package should be generated
+// with a single invocation, like -type Config,Flags.
+//
+// Lazyenvgen is a module of its own, golazy.dev/lazyenv/cmd/lazyenvgen, so the programs that import lazyenv
+// don't depend on golang.org/x/tools. Pin a version instead of latest to generate the same code on every
+// machine. Inside this repository, the go.work file makes it use the lazyenv of the working tree.
+//
+// Only a subset of the features of Fill is supported: the env, json, default, sep, trim, interpolate and secret tags,
+// nested and embedded structs, strings, bools, numbers, time.Duration, types implementing
+// encoding.TextUnmarshaler, and pointers and slices of them. Lazyenvgen fails instead of generating code
+// that would behave differently from Fill for any other type or tag. Like Fill, the generated code reads the
+// value from a file when only the variable with a _FILE suffix is set.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of type names; must be set")
+	output := flag.String("output", "", "output file name; default is lazyenv_gen.go in the package directory")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: lazyenvgen -type T[,T...] [-output file] [package]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pattern := "."
+	if flag.NArg() == 1 {
+		pattern = flag.Arg(0)
+	}
+
+	if err := run(pattern, strings.Split(*typeNames, ","), *output); err != nil {
+		fmt.Fprintf(os.Stderr, "lazyenvgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the fill functions of the types of the package matching pattern and writes them to output.
+func run(pattern string, typeNames []string, output string) error {
+	pkg, err := loadPackage(pattern)
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg.Types, typeNames)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = filepath.Join(packageDir(pkg), "lazyenv_gen.go")
+	}
+	return os.WriteFile(output, src, 0o644)
+}
+
+// loadPackage loads the types of the single package matching pattern.
+func loadPackage(pattern string) (*packages.Package, error) {
+	// The dependencies are type checked from source, so the tool doesn't depend on the export data format of the compiler
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes
+	config := &packages.Config{Mode: mode}
+	pkgs, err := packages.Load(config, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages match %q, expected 1", len(pkgs), pattern)
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return nil, pkgs[0].Errors[0]
+	}
+	return pkgs[0], nil
+}
+
+// packageDir returns the directory of the source files of pkg.
+func packageDir(pkg *packages.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return "."
+	}
+	return filepath.Dir(pkg.GoFiles[0])
+}
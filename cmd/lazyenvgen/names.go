@@ -0,0 +1,103 @@
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// The functions of this file follow the naming rules of the lazyenv package, for go/types instead of reflect.
+// The tests of the example package check that the generated code reads the same keys as Fill.
+
+// skipField reports whether field should not be read from the environment.
+func skipField(field *types.Var, tag reflect.StructTag) bool {
+	if !field.Exported() {
+		if _, ok := types.Unalias(field.Type()).Underlying().(*types.Struct); !field.Embedded() || !ok {
+			return true
+		}
+	}
+	if env, ok := tag.Lookup("env"); ok {
+		return env == "-"
+	}
+	return tag.Get("json") == "-"
+}
+
+// nestedPrefix returns the prefix of the fields of a nested struct field.
+func nestedPrefix(field *types.Var, tag reflect.StructTag, prefix string) string {
+	if field.Embedded() && tag.Get("env") == "" {
+		return prefix
+	}
+	return prefix + fieldEnvNames(field.Name(), tag)[0] + "_"
+}
+
+// structKeys returns the keys of all the environment variables read when filling the struct st,
+// including the _FILE variables.
+func structKeys(st *types.Struct, prefix string) []string {
+	var keys []string
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		if skipField(field, tag) {
+			continue
+		}
+		if isNested(field.Type()) {
+			keys = append(keys, structKeys(structType(field.Type()), nestedPrefix(field, tag, prefix))...)
+			continue
+		}
+		for _, name := range fieldEnvNames(field.Name(), tag) {
+			keys = append(keys, prefix+name)
+		}
+		for _, name := range fieldEnvNames(field.Name(), tag) {
+			keys = append(keys, prefix+name+"_FILE")
+		}
+	}
+	return keys
+}
+
+// structType returns the struct type of t, a struct or a pointer to a struct.
+func structType(t types.Type) *types.Struct {
+	t = types.Unalias(t)
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = types.Unalias(ptr.Elem())
+	}
+	return t.Underlying().(*types.Struct)
+}
+
+// fieldEnvNames returns all the names the environment variable of a field can have, in order of preference.
+func fieldEnvNames(name string, tag reflect.StructTag) []string {
+	if env := tag.Get("env"); env != "" {
+		return strings.Split(env, ",")
+	}
+	if jsonName, _, _ := strings.Cut(tag.Get("json"), ","); jsonName != "" {
+		return []string{strings.ToUpper(strings.ReplaceAll(jsonName, "-", "_"))}
+	}
+	return []string{toEnvName(name)}
+}
+
+// toEnvName converts a field name to an environment variable name.
+func toEnvName(name string) string {
+	runes := []rune(name)
+	var result strings.Builder
+	for i, r := range runes {
+		if i > 0 && isWordBoundary(runes, i) {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return result.String()
+}
+
+// isWordBoundary reports whether a new word starts at runes[i].
+func isWordBoundary(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+	switch {
+	case unicode.IsDigit(prev):
+		return unicode.IsLetter(r)
+	case unicode.IsLower(prev):
+		return unicode.IsUpper(r)
+	case unicode.IsUpper(prev):
+		return unicode.IsUpper(r) && i >= 2 && runes[i-2] != '_' && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package lazyenv
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Config holds a configuration struct of type T that can be reloaded from the environment while it is being read,
+// like a global configuration reloaded on SIGHUP. Reads are lock-free and always see a complete value.
+// The zero value is ready to use, and Load returns the zero value of T until the first Reload.
+type Config[T any] struct {
+	value atomic.Pointer[T]
+}
+
+// NewConfig returns a Config already loaded with Reload.
+func NewConfig[T any]() (*Config[T], error) {
+	config := &Config[T]{}
+	if err := config.Reload(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Load returns the current configuration.
+func (c *Config[T]) Load() T {
+	if value := c.value.Load(); value != nil {
+		return *value
+	}
+	var zero T
+	return zero
+}
+
+// Reload fills a new T with FillE and replaces the current configuration with it.
+// If FillE returns an error the current configuration is kept, so a bad change in the environment
+// doesn't replace a working configuration with a partial one.
+func (c *Config[T]) Reload() error {
+	value := new(T)
+	if err := FillE(value); err != nil {
+		return err
+	}
+	c.value.Store(value)
+	return nil
+}
+
+// Lazy fills a configuration struct of type T the first time it is needed, like the configuration of a library,
+// and returns the same value from then on. It is safe to call Get from several goroutines.
+// The zero value is ready to use.
+type Lazy[T any] struct {
+	once  sync.Once
+	value *T
+	err   error
+}
+
+// Get fills a new T with FillE on the first call and returns it, and the same value and error on later calls.
+// The value is returned even if FillE returns an error, with the fields that could be converted.
+func (l *Lazy[T]) Get() (*T, error) {
+	l.once.Do(func() {
+		l.value = new(T)
+		l.err = FillE(l.value)
+	})
+	return l.value, l.err
+}
@@ -0,0 +1,131 @@
+package lazyenv
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type reloadConfig struct {
+	Host string
+	Port int
+}
+
+func TestConfig(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "80")
+
+	config, err := NewConfig[reloadConfig]()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if got, expect := config.Load(), (reloadConfig{Host: "localhost", Port: 80}); got != expect {
+		t.Errorf("Load() = %+v; expected %+v", got, expect)
+	}
+
+	t.Setenv("PORT", "8080")
+	if err := config.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := config.Load(); got.Port != 8080 {
+		t.Errorf("Load().Port = %d after Reload; expected 8080", got.Port)
+	}
+
+	t.Setenv("PORT", "http")
+	if err := config.Reload(); err == nil {
+		t.Errorf("Reload() error = nil; expected a conversion error")
+	}
+	if got := config.Load(); got.Port != 8080 {
+		t.Errorf("Load().Port = %d after a failed Reload; expected the previous 8080", got.Port)
+	}
+}
+
+func TestConfigZero(t *testing.T) {
+	var config Config[reloadConfig]
+	if got := config.Load(); got != (reloadConfig{}) {
+		t.Errorf("Load() = %+v; expected the zero value before Reload", got)
+	}
+}
+
+func TestConfigConcurrentReload(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "1")
+
+	var config Config[reloadConfig]
+	if err := config.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if got := config.Load(); got.Host != "localhost" || got.Port == 0 {
+					t.Errorf("Load() = %+v; expected a complete configuration", got)
+					return
+				}
+			}
+		}()
+	}
+	for i := 2; i < 100; i++ {
+		t.Setenv("PORT", strconv.Itoa(i))
+		if err := config.Reload(); err != nil {
+			t.Errorf("Reload() error = %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestLazy(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "80")
+
+	var config Lazy[reloadConfig]
+	var wg sync.WaitGroup
+	values := make([]*reloadConfig, 10)
+	for i := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := config.Get()
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+			values[i] = value
+		}()
+	}
+	wg.Wait()
+
+	for _, value := range values {
+		if value != values[0] {
+			t.Fatalf("Get() returned different values; expected the same pointer")
+		}
+	}
+	if expect := (reloadConfig{Host: "localhost", Port: 80}); *values[0] != expect {
+		t.Errorf("Get() = %+v; expected %+v", *values[0], expect)
+	}
+
+	t.Setenv("PORT", "8080")
+	if value, _ := config.Get(); value.Port != 80 {
+		t.Errorf("Get().Port = %d after changing the environment; expected the cached 80", value.Port)
+	}
+}
+
+func TestLazyError(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "http")
+
+	var config Lazy[reloadConfig]
+	value, err := config.Get()
+	if err == nil || value.Host != "localhost" {
+		t.Errorf("Get() = %+v, %v; expected the partial value and a conversion error", value, err)
+	}
+
+	t.Setenv("PORT", "80")
+	if _, again := config.Get(); !reflect.DeepEqual(again, err) {
+		t.Errorf("Get() error = %v on the second call; expected the first error %v", again, err)
+	}
+}
@@ -0,0 +1,104 @@
+package lazyenv
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldDiff describes a field whose current value differs from the value Fill would set.
+type FieldDiff struct {
+	Path    string      // Dotted path of the field, like "DB.Port"
+	Key     string      // Environment variable the field is read from
+	Current interface{} // Value of the field in the struct, or nil if it is inside a nil pointer
+	FromEnv interface{} // Value Fill would set, from the environment or the default tag
+}
+
+// Diff compares the fields of dest with the values Fill would set from the environment, without modifying dest,
+// and returns the fields that differ in declaration order.
+// Fields without a variable set or a default, and values that can't be converted, are not compared.
+// The values of secret fields are reported as "***", so the result can be shown in admin pages.
+// Interface fields are not compared, as their concrete type is only known when filling.
+func Diff(dest interface{}) []FieldDiff {
+	v := reflect.ValueOf(dest)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	type filled struct {
+		path, key string
+		secret    bool
+	}
+	var fields []filled
+	f := &filler{lookup: os.LookupEnv, environ: environNames, files: true, quiet: true, inspect: func(fieldPath, envKey string, source Source, secret bool) {
+		if source != SourceUnset {
+			fields = append(fields, filled{fieldPath, envKey, secret})
+		}
+	}}
+	// Fill a throwaway value so dest is never touched
+	fromEnv := reflect.New(v.Type())
+	f.fill(fromEnv.Interface(), "")
+	failed := map[string]bool{}
+	for _, err := range f.errs {
+		failed[err.Path] = true
+	}
+
+	var diffs []FieldDiff
+	for _, field := range fields {
+		if failed[field.path] {
+			continue
+		}
+		envValue, ok := valueByPath(fromEnv.Elem(), field.path)
+		if !ok {
+			continue
+		}
+		diff := FieldDiff{Path: field.path, Key: field.key, FromEnv: envValue.Interface()}
+		if current, ok := valueByPath(v, field.path); ok {
+			diff.Current = current.Interface()
+		}
+		if reflect.DeepEqual(diff.Current, diff.FromEnv) {
+			continue
+		}
+		if field.secret {
+			diff.Current, diff.FromEnv = secretMask, secretMask
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// valueByPath returns the field of the struct v at the dotted path, following pointers and the indices of
+// indexed slices, like "Servers[0].Host".
+// It returns false if the field is inside a nil pointer, is past the end of a slice or can't be accessed.
+func valueByPath(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		name, index, indexed := strings.Cut(name, "[")
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		if v = v.FieldByName(name); !v.IsValid() {
+			return reflect.Value{}, false
+		}
+		if indexed {
+			i, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+			if err != nil || v.Kind() != reflect.Slice || i >= v.Len() {
+				return reflect.Value{}, false
+			}
+			v = v.Index(i)
+		}
+	}
+	return v, v.CanInterface()
+}
@@ -0,0 +1,81 @@
+package lazyenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type Database struct {
+		Host     string
+		Password string `secret:"true"`
+	}
+	type Config struct {
+		Port     int `default:"80"`
+		Name     string
+		Debug    bool
+		Timeout  int
+		Pattern  string
+		Database *Database
+	}
+
+	t.Setenv("PORT", "8080")
+	t.Setenv("NAME", "app")
+	t.Setenv("DEBUG", "maybe")
+	t.Setenv("PATTERN", "***")
+	t.Setenv("DATABASE_HOST", "db")
+	t.Setenv("DATABASE_PASSWORD", "s3cr3t")
+
+	config := Config{Port: 80, Name: "app", Timeout: 5, Pattern: "*", Database: &Database{Host: "localhost", Password: "old"}}
+	diffs := Diff(&config)
+
+	expect := []FieldDiff{
+		{Path: "Port", Key: "PORT", Current: 80, FromEnv: 8080},
+		{Path: "Pattern", Key: "PATTERN", Current: "*", FromEnv: "***"},
+		{Path: "Database.Host", Key: "DATABASE_HOST", Current: "localhost", FromEnv: "db"},
+		{Path: "Database.Password", Key: "DATABASE_PASSWORD", Current: "***", FromEnv: "***"},
+	}
+	if !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("Diff() = %+v; expected %+v", diffs, expect)
+	}
+	if config.Port != 80 || config.Database.Host != "localhost" {
+		t.Errorf("Diff() modified dest: %+v", config)
+	}
+}
+
+func TestDiffNilPointer(t *testing.T) {
+	type Database struct {
+		Host string
+	}
+	var config struct {
+		Database *Database
+	}
+	t.Setenv("DATABASE_HOST", "db")
+
+	expect := []FieldDiff{{Path: "Database.Host", Key: "DATABASE_HOST", Current: nil, FromEnv: "db"}}
+	if diffs := Diff(&config); !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("Diff() = %+v; expected %+v", diffs, expect)
+	}
+	if config.Database != nil {
+		t.Errorf("Diff() allocated Database")
+	}
+}
+
+func TestDiffIndexedSlice(t *testing.T) {
+	type Server struct {
+		Host string
+	}
+	config := struct {
+		Servers []Server
+	}{Servers: []Server{{Host: "old"}}}
+	t.Setenv("SERVERS_0_HOST", "new")
+	t.Setenv("SERVERS_1_HOST", "added")
+
+	expect := []FieldDiff{
+		{Path: "Servers[0].Host", Key: "SERVERS_0_HOST", Current: "old", FromEnv: "new"},
+		{Path: "Servers[1].Host", Key: "SERVERS_1_HOST", Current: nil, FromEnv: "added"},
+	}
+	if diffs := Diff(&config); !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("Diff() = %+v; expected %+v", diffs, expect)
+	}
+}
@@ -0,0 +1,195 @@
+package lazyenv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFile reads the environment variables defined in a .env file and sets them in the process environment.
+// Each line of the file has the form KEY=value, optionally preceded by "export ".
+// Blank lines and lines starting with # are ignored.
+// Values can be single quoted, which keeps them literally, or double quoted, which interprets escape sequences like \n.
+// Unquoted values end at a " #" comment.
+// Variables that are already set in the environment take precedence and are not overwritten.
+// If a key appears more than once in the file, the last value is used.
+func LoadFile(path string) error {
+	values, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	return setMissing(values)
+}
+
+// Load loads the .env files of the current directory, like LoadDir(".").
+func Load() error {
+	return LoadDir(".")
+}
+
+// LoadDir loads the .env files of dir, following the dotenv convention. The files are, in order of precedence:
+//
+//  1. .env.local, for the overrides of the local machine, usually not committed.
+//  2. .env.{Env()}, like .env.production, for the settings of the current environment.
+//  3. .env, for the defaults.
+//
+// The values of a file override the ones of the files below it, and the variables already set in
+// the process environment override all of them. Missing files are skipped. The format of the files is
+// the same as in LoadFile.
+func LoadDir(dir string) error {
+	values := map[string]string{}
+	for _, name := range []string{".env", ".env." + Env(), ".env.local"} {
+		fileValues, err := readFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		maps.Copy(values, fileValues)
+	}
+	return setMissing(values)
+}
+
+// Parse reads the variables defined in dotenv content from r, with the same format as LoadFile, without
+// touching the process environment. If a key appears more than once, the last value is used.
+// Syntax errors include the number of the line.
+func Parse(r io.Reader) (map[string]string, error) {
+	return parse(r, "")
+}
+
+// readFile returns the variables defined in the .env file at path.
+func readFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parse(file, path)
+}
+
+// parse reads the variables defined in the dotenv content of r.
+// The errors are prefixed with the name of the file, if any, and the number of the line.
+func parse(r io.Reader, name string) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		key, value, ok, err := parseLine(scanner.Text())
+		if err != nil {
+			if name == "" {
+				return nil, fmt.Errorf("lazyenv: line %d: %w", n, err)
+			}
+			return nil, fmt.Errorf("lazyenv: %s:%d: %w", name, n, err)
+		}
+		if ok {
+			values[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// setMissing sets the values in the process environment, except the ones that are already set.
+func setMissing(values map[string]string) error {
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLine parses a single line of a .env file.
+// It returns ok false for blank lines and comments.
+func parseLine(line string) (key, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	line = strings.TrimPrefix(line, "export ")
+
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return "", "", false, fmt.Errorf("missing = in %q", line)
+	}
+	key = strings.TrimSpace(key)
+	if !isValidKey(key) {
+		return "", "", false, fmt.Errorf("invalid key %q", key)
+	}
+
+	value, err = parseValue(strings.TrimSpace(value))
+	if err != nil {
+		return "", "", false, err
+	}
+	return key, value, true, nil
+}
+
+// isValidKey reports whether key is a valid environment variable name.
+func isValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		case r == '.' || r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseValue removes the quotes and comments of a value.
+func parseValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	quote := value[0]
+	if quote != '"' && quote != '\'' {
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	var result strings.Builder
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		if c == quote {
+			rest := strings.TrimSpace(value[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected characters after closing quote: %q", rest)
+			}
+			return result.String(), nil
+		}
+		if c == '\\' && quote == '"' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n':
+				result.WriteByte('\n')
+			case 'r':
+				result.WriteByte('\r')
+			case 't':
+				result.WriteByte('\t')
+			default:
+				result.WriteByte(value[i])
+			}
+			continue
+		}
+		result.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated quoted value %s", value)
+}
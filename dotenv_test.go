@@ -0,0 +1,169 @@
+package lazyenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	content := `# database settings
+DB_NAME=test_db
+export DB_HOST = localhost
+DB_PASS='s3cr#t $HOME'
+GREETING="hello\n\"world\"" # inline comment
+PLAIN=value # comment
+EMPTY=
+
+EXISTING=from_file
+DUPLICATED=first
+DUPLICATED=second
+`
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("EXISTING", "from_env")
+	// t.Setenv restores the variables after the test, so they can be unset safely
+	for _, key := range []string{"DB_NAME", "DB_HOST", "DB_PASS", "GREETING", "PLAIN", "EMPTY", "DUPLICATED"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	if err := LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	expect := map[string]string{
+		"DB_NAME":    "test_db",
+		"DB_HOST":    "localhost",
+		"DB_PASS":    "s3cr#t $HOME",
+		"GREETING":   "hello\n\"world\"",
+		"PLAIN":      "value",
+		"EMPTY":      "",
+		"EXISTING":   "from_env",
+		"DUPLICATED": "second",
+	}
+	for key, value := range expect {
+		got, ok := os.LookupEnv(key)
+		if !ok || got != value {
+			t.Errorf("%s = %q (set %v); expected %q", key, got, ok, value)
+		}
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		line    string
+	}{
+		{"VALID=1\nINVALID\n", ":2:"},
+		{"1KEY=value\n", ":1:"},
+		{"# comment\n\nKEY=\"unterminated\n", ":3:"},
+		{"KEY='quoted' trailing\n", ":1:"},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		err := LoadFile(path)
+		if err == nil || !strings.Contains(err.Error(), path+test.line) {
+			t.Errorf("LoadFile(%q) error = %v; expected an error at line %s", test.content, err, test.line)
+		}
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	if err := LoadFile(filepath.Join(t.TempDir(), ".env")); !os.IsNotExist(err) {
+		t.Errorf("LoadFile() error = %v; expected a not exist error", err)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":            "NAME=base\nHOST=localhost\nPORT=80\nEXISTING=from_file\n",
+		".env.production": "HOST=prod.example.com\nPORT=443\n",
+		".env.staging":    "HOST=staging.example.com\n",
+		".env.local":      "PORT=8443\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetEnv(production)
+	defer ResetEnv()
+	t.Setenv("EXISTING", "from_env")
+	for _, key := range []string{"NAME", "HOST", "PORT"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	if err := LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	expect := map[string]string{
+		"NAME":     "base",
+		"HOST":     "prod.example.com",
+		"PORT":     "8443",
+		"EXISTING": "from_env",
+	}
+	for key, value := range expect {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q; expected %q", key, got, value)
+		}
+	}
+}
+
+func TestLoadDirMissingFiles(t *testing.T) {
+	if err := LoadDir(t.TempDir()); err != nil {
+		t.Errorf("LoadDir() error = %v; expected nil for a directory without .env files", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.local"), []byte("INVALID\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), ".env.local:1:") {
+		t.Errorf("LoadDir() error = %v; expected an error at .env.local:1", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	content := `# comment
+export HOST=localhost
+PASS='s3cr#t'
+GREETING="hello\tworld" # comment
+PORT=80
+PORT=8080
+`
+	values, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	expect := map[string]string{
+		"HOST":     "localhost",
+		"PASS":     "s3cr#t",
+		"GREETING": "hello\tworld",
+		"PORT":     "8080",
+	}
+	if !reflect.DeepEqual(values, expect) {
+		t.Errorf("Parse() = %v; expected %v", values, expect)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("VALID=1\n\nINVALID\n"))
+	expect := `lazyenv: line 3: missing = in "INVALID"`
+	if err == nil || err.Error() != expect {
+		t.Errorf("Parse() error = %v; expected %s", err, expect)
+	}
+}
@@ -0,0 +1,191 @@
+package lazyenv
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Dump returns the environment variables that would fill src with its current values.
+// It is the inverse of Fill: keys follow the same naming rules, and values are formatted so they can be read back by Fill.
+// Slices are joined with commas and maps are written as key:value pairs separated by commas, unless the "sep" and "kvsep" tags say otherwise.
+// In fields tagged with interpolate:"true", "${" is written as "$${", so values that look like references are read back literally.
+// src can be a struct or a pointer to a struct, and anything else gives an empty map. Nil pointers are skipped.
+// Fields tagged with secret:"true", and all the fields of structs tagged with it, are written as "***".
+func Dump(src interface{}) map[string]string {
+	result := map[string]string{}
+	v := reflect.Indirect(reflect.ValueOf(src))
+	if v.Kind() != reflect.Struct {
+		return result
+	}
+	dumpWithPrefix(result, v, "", false)
+	return result
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func dumpWithPrefix(result map[string]string, v reflect.Value, prefix string, secret bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if skipField(field) {
+			continue
+		}
+		fieldValue := v.Field(i)
+		envKey := prefix + fieldEnvName(field)
+		fieldSecret := secret || isSecret(field)
+
+		if fieldValue.Kind() == reflect.Interface && !fieldValue.IsNil() && isNested(fieldValue.Elem().Type()) {
+			fieldValue = fieldValue.Elem()
+		}
+		if isNested(fieldValue.Type()) {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			dumpWithPrefix(result, fieldValue, nestedPrefix(field, prefix, defaultNestedSep), fieldSecret)
+			continue
+		}
+
+		if value, ok := formatValue(fieldValue, field.Tag); ok {
+			if fieldSecret {
+				value = secretMask
+			} else {
+				value = encodeValue(value, field.Tag)
+				if field.Tag.Get("interpolate") == "true" {
+					// Escape the references so the value isn't interpolated when it is read back
+					value = strings.ReplaceAll(value, "${", "$${")
+				}
+			}
+			result[envKey] = value
+		}
+	}
+}
+
+// formatValue formats a value the way setFieldValue reads it.
+// It returns false if the value can't be represented.
+func formatValue(v reflect.Value, tag reflect.StructTag) (string, bool) {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String(), true
+	case timeType:
+		layout := tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Interface().(time.Time).Format(layout), true
+	case urlType:
+		u := v.Interface().(url.URL)
+		return u.String(), true
+	case urlValuesType:
+		return v.Interface().(url.Values).Encode(), true
+	case ipNetType:
+		network := v.Interface().(net.IPNet)
+		return network.String(), true
+	}
+
+	if v.Kind() != reflect.Ptr && reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		if !v.CanAddr() {
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			v = ptr.Elem()
+		}
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", false
+		}
+		return string(text), true
+	}
+
+	if kind := v.Kind(); (kind == reflect.Slice || kind == reflect.Map) && isNested(v.Type().Elem()) && !isSet(v.Type()) {
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", false
+		}
+		return formatValue(v.Elem(), tag)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), true
+		}
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, ok := formatValue(v.Index(i), tag)
+			if !ok {
+				return "", false
+			}
+			values = append(values, value)
+		}
+		if tag.Get("csv") == "true" {
+			return joinCSV(values, tag), true
+		}
+		return strings.Join(values, sliceSep(tag)), true
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, ok := formatValue(iter.Key(), tag)
+			if !ok {
+				return "", false
+			}
+			if isSet(v.Type()) {
+				pairs = append(pairs, key)
+				continue
+			}
+			value, ok := formatValue(iter.Value(), tag)
+			if !ok {
+				return "", false
+			}
+			pairs = append(pairs, key+mapSep(tag)+value)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, sliceSep(tag)), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if tag.Get("char") == "true" {
+			return string(rune(v.Int())), true
+		}
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if tag.Get("char") == "true" {
+			return string(rune(v.Uint())), true
+		}
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), true
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	}
+	return "", false
+}
+
+// joinCSV writes values as a CSV record, the way splitValues reads them.
+func joinCSV(values []string, tag reflect.StructTag) string {
+	var buf strings.Builder
+	writer := csv.NewWriter(&buf)
+	writer.Comma, _ = utf8.DecodeRuneInString(sliceSep(tag))
+	writer.Write(values)
+	writer.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
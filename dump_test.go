@@ -0,0 +1,120 @@
+package lazyenv
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type dumpConfig struct {
+	DB struct {
+		Name     string
+		Password string `env:"PASS"`
+	}
+	Cache      *struct{ Size int }
+	UserID     int
+	Ratio      float64
+	Debug      bool
+	Name       *string
+	Nickname   *string
+	Timeout    time.Duration
+	Hosts      []string
+	MapExample map[string]int
+	Bind       net.IP
+	Homepage   *url.URL
+}
+
+func TestDump(t *testing.T) {
+	var config dumpConfig
+	config.DB.Name = "test_db"
+	config.DB.Password = "secret"
+	config.UserID = 42
+	config.Ratio = 0.5
+	config.Debug = true
+	config.Name = strPtr("app")
+	config.Timeout = 90 * time.Second
+	config.Hosts = []string{"a", "b"}
+	config.MapExample = map[string]int{"b": 2, "a": 1}
+	config.Bind = net.ParseIP("10.0.0.1")
+	config.Homepage, _ = url.Parse("https://example.com/path")
+
+	expect := map[string]string{
+		"DB_NAME":     "test_db",
+		"DB_PASS":     "secret",
+		"USER_ID":     "42",
+		"RATIO":       "0.5",
+		"DEBUG":       "true",
+		"NAME":        "app",
+		"TIMEOUT":     "1m30s",
+		"HOSTS":       "a,b",
+		"MAP_EXAMPLE": "a:1,b:2",
+		"BIND":        "10.0.0.1",
+		"HOMEPAGE":    "https://example.com/path",
+	}
+
+	got := Dump(&config)
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Dump() = %v; expected %v", got, expect)
+	}
+
+	var filled dumpConfig
+	FillFrom(&filled, func(key string) (string, bool) {
+		value, ok := got[key]
+		return value, ok
+	})
+	// Fill always allocates nested struct pointers
+	filled.Cache = nil
+	if !reflect.DeepEqual(filled, config) {
+		t.Errorf("Fill(Dump()) = %+v; expected %+v", filled, config)
+	}
+}
+
+func TestDumpSecrets(t *testing.T) {
+	type Config struct {
+		User     string
+		Password string `secret:"true"`
+		Token    []byte `secret:"true" encoding:"base64"`
+		DB       struct {
+			Host string
+			Pass string `secret:"true"`
+		}
+		Keys struct {
+			Private string
+		} `secret:"true"`
+	}
+
+	var config Config
+	config.User = "admin"
+	config.Password = "hunter2"
+	config.Token = []byte("token")
+	config.DB.Host = "localhost"
+	config.DB.Pass = "secret"
+	config.Keys.Private = "key"
+
+	expect := map[string]string{
+		"USER":         "admin",
+		"PASSWORD":     "***",
+		"TOKEN":        "***",
+		"DB_HOST":      "localhost",
+		"DB_PASS":      "***",
+		"KEYS_PRIVATE": "***",
+	}
+	if got := Dump(config); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Dump() = %v; expected %v", got, expect)
+	}
+}
+
+func TestDumpInvalidSrc(t *testing.T) {
+	type Config struct {
+		Port int
+	}
+
+	port := 80
+	for _, src := range []interface{}{nil, (*Config)(nil), port, &port} {
+		if got := Dump(src); len(got) != 0 {
+			t.Errorf("Dump(%T) = %v; expected an empty map", src, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package lazyenv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isoDateUnits and isoTimeUnits are the durations of the ISO 8601 designators before and after the T, in order.
+// Years and months are not included, as they don't have a fixed duration.
+var (
+	isoDateUnits = []isoUnit{{'W', 7 * 24 * time.Hour}, {'D', 24 * time.Hour}}
+	isoTimeUnits = []isoUnit{{'H', time.Hour}, {'M', time.Minute}, {'S', time.Second}}
+)
+
+type isoUnit struct {
+	designator byte
+	duration   time.Duration
+}
+
+// parseISODuration parses an ISO 8601 duration like PT1H30M, P1DT12H or PT0.5S.
+// Weeks, days, hours, minutes and seconds are accepted, and the last one can have a fraction.
+// A leading - makes the duration negative.
+func parseISODuration(value string) (time.Duration, error) {
+	rest, negative := strings.CutPrefix(strings.ToUpper(value), "-")
+	rest, ok := strings.CutPrefix(rest, "P")
+	if !ok || rest == "" || rest == "T" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", value)
+	}
+
+	date, clock, hasClock := strings.Cut(rest, "T")
+	if hasClock && clock == "" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", value)
+	}
+	total, err := parseISOUnits(date, isoDateUnits)
+	if err == nil {
+		var clockTotal float64
+		clockTotal, err = parseISOUnits(clock, isoTimeUnits)
+		total += clockTotal
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", value, err)
+	}
+	if total > math.MaxInt64 {
+		return 0, errors.New("duration out of range")
+	}
+	if negative {
+		total = -total
+	}
+	return time.Duration(math.Round(total)), nil
+}
+
+// parseISOUnits returns the nanoseconds of the components of one part of an ISO 8601 duration, like 1H30M.
+// The components must follow the order of units, and only the last one can have a fraction.
+func parseISOUnits(part string, units []isoUnit) (float64, error) {
+	var total float64
+	for part != "" {
+		i := strings.IndexFunc(part, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+		if i <= 0 {
+			return 0, fmt.Errorf("expected a number in %q", part)
+		}
+		for len(units) > 0 && units[0].designator != part[i] {
+			units = units[1:]
+		}
+		if len(units) == 0 {
+			if part[i] == 'Y' || part[i] == 'M' {
+				return 0, errors.New("years and months don't have a fixed duration")
+			}
+			return 0, fmt.Errorf("unexpected designator %q", part[i])
+		}
+		number, err := strconv.ParseFloat(strings.Replace(part[:i], ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", part[:i])
+		}
+		if strings.ContainsAny(part[:i], ".,") && i+1 < len(part) {
+			return 0, errors.New("only the last component can have a fraction")
+		}
+		total += number * float64(units[0].duration)
+		units = units[1:]
+		part = part[i+1:]
+	}
+	return total, nil
+}
@@ -0,0 +1,63 @@
+package lazyenv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"PT1H30M", 90 * time.Minute, true},
+		{"PT45S", 45 * time.Second, true},
+		{"P1DT12H", 36 * time.Hour, true},
+		{"P2W", 14 * 24 * time.Hour, true},
+		{"PT0.5S", 500 * time.Millisecond, true},
+		{"PT1,5M", 90 * time.Second, true},
+		{"pt10m", 10 * time.Minute, true},
+		{"-PT1M", -time.Minute, true},
+		{"P1Y", 0, false},
+		{"P1M", 0, false},
+		{"PT", 0, false},
+		{"P", 0, false},
+		{"P1DT", 0, false},
+		{"PT30M1H", 0, false},
+		{"PT1.5H30M", 0, false},
+		{"1h30m", 0, false},
+		{"PTH", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := parseISODuration(test.input)
+			if (err == nil) != test.ok || result != test.expected {
+				t.Errorf("parseISODuration(%s) = %v, %v; expected %v, success %v", test.input, result, err, test.expected, test.ok)
+			}
+		})
+	}
+}
+
+func TestFillISO8601(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration  `iso8601:"true"`
+		Interval *time.Duration `iso8601:"true" default:"PT45S"`
+		Delay    time.Duration
+	}
+	env := map[string]string{"TIMEOUT": "PT1H30M", "DELAY": "1m"}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if config.Timeout != 90*time.Minute || config.Interval == nil || *config.Interval != 45*time.Second || config.Delay != time.Minute {
+		t.Errorf("FillWithOptions() = %+v; expected the ISO 8601 durations to be parsed", config)
+	}
+
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"TIMEOUT": "90m"})})
+	if err == nil {
+		t.Errorf("FillWithOptions() error = nil; expected an error for a Go duration in an iso8601 field")
+	}
+}
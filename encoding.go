@@ -0,0 +1,40 @@
+package lazyenv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// decodeValue decodes a value according to the "encoding" tag of its field.
+// Padding is optional for both base64 and base64url.
+func decodeValue(value string, tag reflect.StructTag) (string, error) {
+	var encoding *base64.Encoding
+	switch name := tag.Get("encoding"); name {
+	case "":
+		return value, nil
+	case "base64":
+		encoding = base64.RawStdEncoding
+	case "base64url":
+		encoding = base64.RawURLEncoding
+	default:
+		return "", fmt.Errorf("unknown encoding %q", name)
+	}
+	decoded, err := encoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// encodeValue encodes a value according to the "encoding" tag of its field.
+func encodeValue(value string, tag reflect.StructTag) string {
+	switch tag.Get("encoding") {
+	case "base64":
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	case "base64url":
+		return base64.URLEncoding.EncodeToString([]byte(value))
+	}
+	return value
+}
@@ -0,0 +1,51 @@
+package lazyenv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type encodedConfig struct {
+	Key    []byte `encoding:"base64"`
+	Cert   string `encoding:"base64url"`
+	Plain  string
+	Hosts  []string `encoding:"base64"`
+	Secret []byte   `encoding:"base64"`
+}
+
+func TestFillEncoding(t *testing.T) {
+	t.Setenv("KEY", "aGVsbG8=")
+	t.Setenv("CERT", "Pz8_Pw")
+	t.Setenv("PLAIN", "aGVsbG8=")
+	t.Setenv("HOSTS", "YSxi")
+
+	var config encodedConfig
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := encodedConfig{
+		Key:   []byte("hello"),
+		Cert:  "????",
+		Plain: "aGVsbG8=",
+		Hosts: []string{"a", "b"},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+
+	t.Setenv("SECRET", "not base64!")
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "SECRET" {
+		t.Errorf("FillE() error = %v; expected a FieldError for SECRET", err)
+	}
+}
+
+func TestDumpEncoding(t *testing.T) {
+	config := encodedConfig{Key: []byte("hello"), Cert: "????"}
+	got := Dump(config)
+	if got["KEY"] != "aGVsbG8=" || got["CERT"] != "Pz8_Pw==" {
+		t.Errorf("Dump() = %v; expected encoded values", got)
+	}
+}
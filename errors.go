@@ -0,0 +1,92 @@
+package lazyenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The errors of the fields wrap one of these errors, depending on what failed, so they can be told apart with errors.Is.
+var (
+	// ErrRequired is the error of a field tagged with required:"true" that has no variable set and no default.
+	ErrRequired = errors.New("required variable is not set")
+	// ErrConvert is wrapped by the errors of the values that can't be read or converted to the type of their field,
+	// including the files of the _FILE variables, template defaults and the JSON document of FillJSON.
+	ErrConvert = errors.New("cannot convert value")
+	// ErrValidate is wrapped by the errors of the values outside the range of their "min" and "max" tags
+	// or not in their "oneof" tag, of the groups that don't have exactly one field set,
+	// and of the structs whose Validate method fails.
+	ErrValidate = errors.New("invalid value")
+)
+
+// invalidDestError is returned when the destination of a fill is not a non-nil pointer to the kind of value expected.
+type invalidDestError struct {
+	function string      // Name of the function that was called, like "Fill"
+	expected string      // What the pointer should point to, like "a struct"
+	dest     interface{} // Destination that was given
+}
+
+func (e *invalidDestError) Error() string {
+	got := fmt.Sprintf("%T", e.dest)
+	v := reflect.ValueOf(e.dest)
+	switch {
+	case e.dest == nil:
+		got = "nil"
+	case v.Kind() == reflect.Ptr && v.IsNil():
+		got = "a nil " + got
+	}
+	return fmt.Sprintf("lazyenv: %s requires a non-nil pointer to %s, got %s", e.function, e.expected, got)
+}
+
+// FieldError describes a struct field that could not be filled from the environment.
+type FieldError struct {
+	Path  string       // Dotted path of the field in the struct, like "DB.Port". Empty for the root struct
+	Key   string       // Environment variable the value was read from. Empty for validation errors
+	Value string       // Raw value of the environment variable, if it was set
+	Kind  reflect.Kind // Kind of the field that was being filled
+	Err   error        // Underlying error
+
+	category error // ErrConvert or ErrValidate, nil for the errors of required fields
+}
+
+func (e *FieldError) Error() string {
+	switch {
+	case e.Path != "" && e.Key != "":
+		return fmt.Sprintf("lazyenv: %s (%s): %v", e.Path, e.Key, e.Err)
+	case e.Path != "":
+		return fmt.Sprintf("lazyenv: %s: %v", e.Path, e.Err)
+	case e.Key != "":
+		return fmt.Sprintf("lazyenv: %s: %v", e.Key, e.Err)
+	}
+	return "lazyenv: " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error of the kind of failure, like ErrConvert.
+func (e *FieldError) Is(target error) bool {
+	return e.category != nil && target == e.category
+}
+
+// Errors collects all the errors found while filling a struct.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual errors so they can be inspected with errors.Is and errors.As.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,83 @@
+package lazyenv
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+)
+
+// GetString returns the value of the environment variable key, or fallback if it is not set.
+func GetString(key, fallback string) string {
+	return get(key, fallback)
+}
+
+// GetInt returns the value of the environment variable key as an int.
+// It returns fallback if the variable is not set or is not a valid int.
+func GetInt(key string, fallback int) int {
+	return get(key, fallback)
+}
+
+// GetBool returns the value of the environment variable key as a bool, using the same rules as Fill.
+// It returns fallback if the variable is not set or is not a valid bool.
+func GetBool(key string, fallback bool) bool {
+	return get(key, fallback)
+}
+
+// GetDuration returns the value of the environment variable key parsed with time.ParseDuration.
+// It returns fallback if the variable is not set or is not a valid duration.
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	return get(key, fallback)
+}
+
+// get reads the environment variable key and converts it to the type of fallback.
+func get[T any](key string, fallback T) T {
+	envValue, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var value T
+	if err := (&filler{}).setFieldValue(reflect.ValueOf(&value).Elem(), envValue, ""); err != nil {
+		return fallback
+	}
+	return value
+}
+
+// MustGetString returns the value of the environment variable key. It panics if the variable is not set.
+func MustGetString(key string) string {
+	return mustGet[string](key)
+}
+
+// MustGetInt returns the value of the environment variable key as an int.
+// It panics if the variable is not set or is not a valid int.
+func MustGetInt(key string) int {
+	return mustGet[int](key)
+}
+
+// MustGetBool returns the value of the environment variable key as a bool, using the same rules as Fill.
+// It panics if the variable is not set or is not a valid bool.
+func MustGetBool(key string) bool {
+	return mustGet[bool](key)
+}
+
+// MustGetDuration returns the value of the environment variable key parsed with time.ParseDuration.
+// It panics if the variable is not set or is not a valid duration.
+func MustGetDuration(key string) time.Duration {
+	return mustGet[time.Duration](key)
+}
+
+// mustGet reads the environment variable key and converts it to T.
+// The panic value is a *FieldError naming the key and the expected type.
+func mustGet[T any](key string) T {
+	var value T
+	fieldValue := reflect.ValueOf(&value).Elem()
+	envValue, ok := os.LookupEnv(key)
+	if !ok {
+		panic(&FieldError{Key: key, Kind: fieldValue.Kind(), Err: fmt.Errorf("%w (expected %s)", ErrRequired, fieldValue.Type())})
+	}
+	if err := (&filler{}).setFieldValue(fieldValue, envValue, ""); err != nil {
+		panic(&FieldError{Key: key, Value: envValue, Kind: fieldValue.Kind(), category: ErrConvert,
+			Err: fmt.Errorf("cannot convert %q to %s: %w", envValue, fieldValue.Type(), err)})
+	}
+	return value
+}
@@ -0,0 +1,100 @@
+package lazyenv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	t.Setenv("NAME", "app")
+	t.Setenv("EMPTY", "")
+	t.Setenv("PORT", "9090")
+	t.Setenv("BAD_PORT", "http")
+	t.Setenv("DEBUG", "T")
+	t.Setenv("BAD_DEBUG", "maybe")
+	t.Setenv("TIMEOUT", "1m")
+	t.Setenv("BAD_TIMEOUT", "60")
+
+	if got := GetString("NAME", "default"); got != "app" {
+		t.Errorf("GetString(NAME) = %q; expected %q", got, "app")
+	}
+	if got := GetString("EMPTY", "default"); got != "" {
+		t.Errorf("GetString(EMPTY) = %q; expected empty string", got)
+	}
+	if got := GetString("MISSING", "default"); got != "default" {
+		t.Errorf("GetString(MISSING) = %q; expected %q", got, "default")
+	}
+
+	if got := GetInt("PORT", 8080); got != 9090 {
+		t.Errorf("GetInt(PORT) = %d; expected 9090", got)
+	}
+	if got := GetInt("BAD_PORT", 8080); got != 8080 {
+		t.Errorf("GetInt(BAD_PORT) = %d; expected 8080", got)
+	}
+	if got := GetInt("MISSING", 8080); got != 8080 {
+		t.Errorf("GetInt(MISSING) = %d; expected 8080", got)
+	}
+
+	if got := GetBool("DEBUG", false); !got {
+		t.Errorf("GetBool(DEBUG) = %v; expected true", got)
+	}
+	if got := GetBool("BAD_DEBUG", true); !got {
+		t.Errorf("GetBool(BAD_DEBUG) = %v; expected the fallback", got)
+	}
+
+	if got := GetDuration("TIMEOUT", time.Second); got != time.Minute {
+		t.Errorf("GetDuration(TIMEOUT) = %v; expected 1m", got)
+	}
+	if got := GetDuration("BAD_TIMEOUT", time.Second); got != time.Second {
+		t.Errorf("GetDuration(BAD_TIMEOUT) = %v; expected the fallback", got)
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	t.Setenv("NAME", "app")
+	t.Setenv("PORT", "9090")
+	t.Setenv("BAD_PORT", "http")
+	t.Setenv("DEBUG", "yes")
+	t.Setenv("TIMEOUT", "1m")
+
+	if got := MustGetString("NAME"); got != "app" {
+		t.Errorf("MustGetString(NAME) = %q; expected %q", got, "app")
+	}
+	if got := MustGetInt("PORT"); got != 9090 {
+		t.Errorf("MustGetInt(PORT) = %d; expected 9090", got)
+	}
+	if got := MustGetBool("DEBUG"); !got {
+		t.Errorf("MustGetBool(DEBUG) = %v; expected true", got)
+	}
+	if got := MustGetDuration("TIMEOUT"); got != time.Minute {
+		t.Errorf("MustGetDuration(TIMEOUT) = %v; expected 1m", got)
+	}
+
+	tests := []struct {
+		name   string
+		get    func()
+		expect string
+	}{
+		{
+			name:   "missing",
+			get:    func() { MustGetInt("MISSING") },
+			expect: "lazyenv: MISSING: required variable is not set (expected int)",
+		},
+		{
+			name:   "invalid",
+			get:    func() { MustGetInt("BAD_PORT") },
+			expect: `lazyenv: BAD_PORT: cannot convert "http" to int: strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || err.Error() != tt.expect {
+					t.Errorf("panic = %v; expected %s", err, tt.expect)
+				}
+			}()
+			tt.get()
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package lazyenv
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// isIndexedSlice reports whether t is a slice of structs, or of pointers to structs,
+// that can be filled from indexed variables like SERVERS_0_HOST.
+func isIndexedSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && isNested(t.Elem())
+}
+
+// maxIndexedLen is the maximum length of a slice filled from indexed variables, so a stray variable
+// with a huge index like SERVERS_99999999999_HOST is an error instead of a huge allocation.
+const maxIndexedLen = 1024
+
+// fillIndexed fills the slice of structs v from the variables prefix<n>_*, like SERVERS_0_HOST and SERVERS_1_HOST,
+// where the separator after the index is the one of the NestedSep option.
+// The slice grows to cover the highest index found, and the indices without variables get zero elements.
+// Indices of maxIndexedLen or more are reported as errors.
+// It reports whether any indexed variable was found.
+func (f *filler) fillIndexed(v reflect.Value, prefix, path string, secret bool) bool {
+	length, overKey := f.indexedLen(prefix, v.Type().Elem())
+	if overKey != "" {
+		value, _ := f.lookup(overKey)
+		f.failAs(ErrConvert, path, overKey, value, v.Kind(), fmt.Errorf("index is over the maximum of %d", maxIndexedLen-1))
+		return true
+	}
+	if length == 0 {
+		return false
+	}
+
+	slice := reflect.MakeSlice(v.Type(), length, length)
+	for i := 0; i < length; i++ {
+		elem := slice.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			elem = elem.Elem()
+		}
+		index := strconv.Itoa(i)
+		f.fillWithPrefix(elem, prefix+index+f.nestedSeparator(), path+"["+index+"].", secret)
+	}
+	v.Set(slice)
+	return true
+}
+
+// indexedLen returns one more than the highest index n of the variables prefix<n>_*, or 0 if there are none.
+// Only the variables that fill a field of elem count, and overKey is the first one with an index of
+// maxIndexedLen or more, if any.
+// When the variables can't be listed, because dest is filled with a custom or case insensitive lookup,
+// the indices are probed from 0 with the keys of elem, and the first index without variables ends the slice.
+func (f *filler) indexedLen(prefix string, elem reflect.Type) (length int, overKey string) {
+	sep := f.nestedSeparator()
+	if f.environ == nil {
+		for length < maxIndexedLen && f.hasStructKey(elem, prefix+strconv.Itoa(length)+sep) {
+			length++
+		}
+		return length, ""
+	}
+
+	for _, name := range f.environ() {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		digits, _, ok := strings.Cut(rest, sep)
+		if !ok || !isIndex(digits) || !isElemKey(elem, prefix+digits+sep, sep, name, f.files) {
+			continue
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil || n >= maxIndexedLen {
+			return 0, name
+		}
+		if n >= length {
+			length = n + 1
+		}
+	}
+	return length, ""
+}
+
+// hasStructKey reports whether any of the variables of the struct type t is set, including the indexed variables
+// of its slices of structs, which structKeys can't list.
+func (f *filler) hasStructKey(t reflect.Type, prefix string) bool {
+	if f.hasAnyKey(structKeys(t, prefix, f.nestedSeparator(), f.files)) {
+		return true
+	}
+	for _, slice := range indexedSlices(t, prefix, f.nestedSeparator()) {
+		if length, overKey := f.indexedLen(slice.prefix, slice.elem); length > 0 || overKey != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isIndex reports whether s is the index of an indexed variable, a non-empty string of digits.
+func isIndex(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// hasAnyKey reports whether any of the keys is set.
+func (f *filler) hasAnyKey(keys []string) bool {
+	for _, key := range keys {
+		if _, ok := f.lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// environNames returns the names of the variables of the process environment.
+func environNames() []string {
+	var names []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+package lazyenv
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// interpolate replaces the ${NAME} references in value with the values of the variables, read with the lookup of the filler.
+// Variables that are not set get the value of the field filled from them, if it was declared before and has a default.
+// The values of the referenced variables are interpolated too. visiting has the names being interpolated,
+// so a variable that references itself, directly or through others, is an error instead of an endless loop.
+// "$${" is written as a literal "${" and a "${" without a closing brace is kept as is.
+// Undefined references expand to an empty string, or are an error in strict mode.
+func (f *filler) interpolate(value string, visiting []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var result strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		if start > 0 && value[start-1] == '$' {
+			result.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := value[start+2 : start+end]
+		result.WriteString(value[:start])
+		value = value[start+end+1:]
+
+		if slices.Contains(visiting, name) {
+			return "", fmt.Errorf("reference cycle in ${%s}", name)
+		}
+		ref, ok := f.lookup(name)
+		if !ok {
+			if resolved, ok := f.resolved[name]; ok {
+				// The variable isn't set but a field declared before got it from its default
+				result.WriteString(resolved)
+				continue
+			}
+			if f.strict {
+				return "", fmt.Errorf("undefined variable ${%s}", name)
+			}
+			continue
+		}
+		ref, err := f.interpolate(ref, append(visiting, name))
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(ref)
+	}
+	result.WriteString(value)
+	return result.String(), nil
+}
+
+// expand replaces the $NAME and ${NAME} references in value like os.ExpandEnv, but with the lookup of the filler.
+// Like in interpolate, variables that are not set get the resolved value of a field declared before, if any.
+// References to undefined variables expand to an empty string.
+func (f *filler) expand(value string) string {
+	return os.Expand(value, func(name string) string {
+		if ref, ok := f.lookup(name); ok {
+			return ref
+		}
+		return f.resolved[name]
+	})
+}
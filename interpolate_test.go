@@ -0,0 +1,143 @@
+package lazyenv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFillInterpolation(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+	t.Setenv("PORT", "8080")
+	t.Setenv("ADDR", "${HOST}:${PORT}")
+	t.Setenv("API", "http://${ADDR}/api")
+	t.Setenv("TEMPLATE", "$${HOST} is ${HOST}")
+	t.Setenv("UNCLOSED", "${HOST")
+	t.Setenv("PASSWORD", "pa${HOST}ss")
+
+	type Config struct {
+		URL      string `interpolate:"true" default:"http://${HOST}:${PORT}"`
+		API      string `interpolate:"true"`
+		Template string `interpolate:"true"`
+		Unclosed string `interpolate:"true"`
+		Missing  string `interpolate:"true" default:"[${UNDEFINED}]"`
+		Timeout  int    `interpolate:"true" default:"${PORT}"`
+		Password string
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		URL:      "http://localhost:8080",
+		API:      "http://localhost:8080/api",
+		Template: "${HOST} is localhost",
+		Unclosed: "${HOST",
+		Missing:  "[]",
+		Timeout:  8080,
+		Password: "pa${HOST}ss",
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillInterpolationErrors(t *testing.T) {
+	t.Setenv("SELF", "${SELF}")
+	t.Setenv("LOOP_A", "${LOOP_B}")
+	t.Setenv("LOOP_B", "${LOOP_A}")
+
+	var config struct {
+		Self    string `interpolate:"true"`
+		LoopA   string `interpolate:"true"`
+		Missing string `interpolate:"true" default:"${UNDEFINED}"`
+		Plain   string `default:"${UNDEFINED}"`
+	}
+
+	err := FillE(&config)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("FillE() error = %v; expected errors for SELF and LOOP_A", err)
+	}
+
+	err = FillStrict(&config)
+	if !errors.As(err, &errs) || len(errs) != 3 || errs[2].Path != "Missing" {
+		t.Errorf("FillStrict() error = %v; expected errors for SELF, LOOP_A and Missing", err)
+	}
+}
+
+func TestDumpInterpolation(t *testing.T) {
+	type Config struct {
+		Template string `interpolate:"true"`
+		Raw      string
+	}
+	config := Config{Template: "${HOST}", Raw: "${HOST}"}
+
+	dumped := Dump(&config)
+	if dumped["TEMPLATE"] != "$${HOST}" || dumped["RAW"] != "${HOST}" {
+		t.Errorf("Dump() = %v; expected TEMPLATE=$${HOST} and RAW=${HOST}", dumped)
+	}
+
+	var filled Config
+	FillFrom(&filled, mapLookup(dumped))
+	if filled != config {
+		t.Errorf("Fill(Dump()) = %+v; expected %+v", filled, config)
+	}
+}
+
+func TestFillInterpolationOrder(t *testing.T) {
+	t.Setenv("PORT", "9090")
+
+	type Config struct {
+		Early string `interpolate:"true" default:"${HOST}"`
+		Host  string `default:"localhost"`
+		Port  int    `default:"8080"`
+		DB    struct {
+			Name string `default:"app"`
+		}
+		URL string `interpolate:"true" default:"http://${HOST}:${PORT}/${DB_NAME}"`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	if config.URL != "http://localhost:9090/app" {
+		t.Errorf("URL = %q; expected %q", config.URL, "http://localhost:9090/app")
+	}
+	if config.Early != "" {
+		t.Errorf("Early = %q; expected an empty string, as Host is declared after it", config.Early)
+	}
+}
+
+func TestFillExpand(t *testing.T) {
+	t.Setenv("HOME", "/home/app")
+	t.Setenv("CONFIG", "$HOME/config")
+	t.Setenv("CACHE", "${HOME}/cache/$UNDEFINED")
+	t.Setenv("PLAIN", "$HOME/plain")
+
+	type Config struct {
+		Config string `expand:"true"`
+		Cache  string `expand:"true"`
+		Plain  string
+		Paths  []string `expand:"true" default:"$HOME/a,$HOME/b"`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Config: "/home/app/config",
+		Cache:  "/home/app/cache/",
+		Plain:  "$HOME/plain",
+		Paths:  []string{"/home/app/a", "/home/app/b"},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
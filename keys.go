@@ -0,0 +1,294 @@
+package lazyenv
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// UnknownKeys returns the environment variables starting with prefix that dest doesn't read, sorted by name.
+// It is useful to catch misspelled variables, like MYAPP_PROT instead of MYAPP_PORT.
+// The prefix is handled like in FillPrefixed, but it can't be empty, as every other variable of the environment
+// would be unknown. If the prefix is empty or dest is not a struct or a pointer to a struct, UnknownKeys returns nil.
+func UnknownKeys(dest interface{}, prefix string) []string {
+	t, ok := destType(dest)
+	prefix = keyPrefix(prefix)
+	if !ok || prefix == "" {
+		return nil
+	}
+	keys := map[string]bool{}
+	for _, key := range structKeys(t, prefix, defaultNestedSep, true) {
+		keys[key] = true
+	}
+
+	var unknown []string
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		if strings.HasPrefix(key, prefix) && !keys[key] && !isIndexedKey(t, prefix, defaultNestedSep, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// Unset removes from the environment all the variables that Fill would read for dest,
+// including alternate names and the variables of nested structs.
+// It only depends on the shape of dest, not on which variables are set, so it is useful to clean up after tests.
+// The exception are the indexed variables of slices of structs, like SERVERS_0_HOST, found by listing the environment.
+// If dest is not a struct or a pointer to a struct, Unset does nothing.
+func Unset(dest interface{}) {
+	t, ok := destType(dest)
+	if !ok {
+		return
+	}
+	for _, key := range structKeys(t, "", defaultNestedSep, true) {
+		os.Unsetenv(key)
+	}
+	for _, key := range environNames() {
+		if isIndexedKey(t, "", defaultNestedSep, key) {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+// FieldPlan describes how Fill would fill a field.
+type FieldPlan struct {
+	Path           string // Dotted path of the field, like "DB.Port"
+	Key            string // Environment variable the field is read from
+	Found          bool   // Whether the environment variable is set
+	RawValue       string // Value that would be converted, from the environment or the default tag
+	WillUseDefault bool   // Whether the value comes from the default tag
+}
+
+// Plan returns what Fill would do for each field of dest, in declaration order, without modifying dest.
+// The values of secret fields are masked like in FillWithHook.
+// If dest is not a struct or a pointer to a struct, Plan returns nil.
+func Plan(dest interface{}) []FieldPlan {
+	t, ok := destType(dest)
+	if !ok {
+		return nil
+	}
+
+	var plan []FieldPlan
+	f := &filler{lookup: os.LookupEnv, environ: environNames, files: true, quiet: true, hook: func(fieldPath, envKey, rawValue string, source Source) {
+		plan = append(plan, FieldPlan{
+			Path:           fieldPath,
+			Key:            envKey,
+			Found:          source == SourceEnv,
+			RawValue:       rawValue,
+			WillUseDefault: source == SourceDefault,
+		})
+	}}
+	// Fill a throwaway value so dest is never touched
+	f.fill(reflect.New(t).Interface(), "")
+	return plan
+}
+
+// Report summarizes the environment variables read by FillReport.
+type Report struct {
+	Consumed []string // Keys that were set and assigned to their field, in declaration order
+	Missing  []string // Keys that were looked up but not set, whether their field got a default or not
+	Errors   Errors   // Errors of the fill, the same returned by FillReport
+}
+
+// FillReport fills dest like FillE and returns which environment variables it used, for audit logs.
+// The keys include the prefixes of nested structs. Keys that were set but couldn't be assigned, like values that
+// can't be converted, are neither consumed nor missing, and their errors are in the Errors of the report.
+func FillReport(dest interface{}) (Report, error) {
+	var report Report
+	var consumed []string
+	err := FillWithOptions(dest, Options{Hook: func(fieldPath, envKey, rawValue string, source Source) {
+		if source == SourceEnv {
+			consumed = append(consumed, envKey)
+		} else {
+			report.Missing = append(report.Missing, envKey)
+		}
+	}})
+	errors.As(err, &report.Errors)
+
+	failed := map[string]bool{}
+	for _, fieldErr := range report.Errors {
+		failed[fieldErr.Key] = true
+	}
+	for _, key := range consumed {
+		if !failed[key] {
+			report.Consumed = append(report.Consumed, key)
+		}
+	}
+	return report, err
+}
+
+// destType returns the struct type of dest, a struct or a pointer to a struct, which can be nil as only its type is used.
+// It returns false if dest is anything else.
+func destType(dest interface{}) (reflect.Type, bool) {
+	t := reflect.TypeOf(dest)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t != nil && t.Kind() == reflect.Struct
+}
+
+// structKeys returns the keys of all the environment variables read when filling a struct of type t,
+// with the names of nested structs joined with sep. t can be a struct or a pointer to a struct.
+// files adds the _FILE variables, which are only read from the process environment.
+func structKeys(t reflect.Type, prefix, sep string, files bool) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if skipField(field) {
+			continue
+		}
+		if isNested(field.Type) {
+			keys = append(keys, structKeys(field.Type, nestedPrefix(field, prefix, sep), sep, files)...)
+			continue
+		}
+		for _, name := range fieldEnvNames(field) {
+			keys = append(keys, prefix+name)
+		}
+		if files {
+			for _, name := range fieldEnvNames(field) {
+				keys = append(keys, prefix+name+"_FILE")
+			}
+		}
+		if isTextStruct(field.Type) {
+			keys = append(keys, structKeys(field.Type, nestedPrefix(field, prefix, sep), sep, files)...)
+		}
+	}
+	return keys
+}
+
+// indexedSlice is a slice of structs filled from indexed variables, like SERVERS_0_HOST for a Servers []Server field.
+type indexedSlice struct {
+	prefix string       // Prefix of the indexed variables, before the index, like "SERVERS_"
+	elem   reflect.Type // Type of the elements of the slice
+}
+
+// indexedSlices returns the slices of structs of the struct type t and of its nested structs.
+// t can be a struct or a pointer to a struct.
+func indexedSlices(t reflect.Type, prefix, sep string) []indexedSlice {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var indexed []indexedSlice
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if skipField(field) {
+			continue
+		}
+		switch {
+		case isNested(field.Type):
+			indexed = append(indexed, indexedSlices(field.Type, nestedPrefix(field, prefix, sep), sep)...)
+		case isIndexedSlice(field.Type):
+			indexed = append(indexed, indexedSlice{nestedPrefix(field, prefix, sep), field.Type.Elem()})
+		}
+	}
+	return indexed
+}
+
+// isIndexedKey reports whether key is one of the indexed variables read when filling a struct of type t,
+// like SERVERS_0_HOST, which structKeys can't list.
+func isIndexedKey(t reflect.Type, prefix, sep, key string) bool {
+	for _, slice := range indexedSlices(t, prefix, sep) {
+		rest, ok := strings.CutPrefix(key, slice.prefix)
+		if !ok {
+			continue
+		}
+		index, _, ok := strings.Cut(rest, sep)
+		if !ok || !isIndex(index) {
+			continue
+		}
+		if isElemKey(slice.elem, slice.prefix+index+sep, sep, key, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// isElemKey reports whether key is one of the variables read when filling an element of type elem of
+// a slice of structs, whose variables start with prefix, like SERVERS_0_.
+// files includes the _FILE variables, which are only read from the process environment.
+func isElemKey(elem reflect.Type, prefix, sep, key string, files bool) bool {
+	return slices.Contains(structKeys(elem, prefix, sep, files), key) || isIndexedKey(elem, prefix, sep, key)
+}
+
+// EnvKey describes an environment variable read by Fill, for documentation.
+type EnvKey struct {
+	Path       string   // Dotted path of the field, like "DB.Port"
+	Key        string   // Name of the environment variable, including the prefixes of the nested structs
+	Alternates []string // Other names the variable can have, from the env tag, in order of preference
+	Type       string   // Go type of the field, like "int" or "[]string"
+	Required   bool     // Whether the field is tagged with required:"true"
+	Default    string   // Value of the default tag
+	HasDefault bool     // Whether the field has a default tag, as the default can be empty
+	OneOf      []string // Allowed values from the oneof or oneof_ci tag, if any
+	Secret     bool     // Whether the value is secret, from the secret tag of the field or of a parent struct
+}
+
+// Keys returns the environment variables dest reads, one for each field that is not a nested struct,
+// in declaration order. It only depends on the type of dest, so it can be used to document the configuration.
+// Interface fields are not included, as their concrete type is only known when filling.
+// A slice of structs is followed by the indexed variables of its elements, with <n> in place of the index,
+// like SERVERS_<n>_HOST with the path Servers[<n>].Host. If dest is not a struct or a pointer to a struct, Keys returns nil.
+func Keys(dest interface{}) []EnvKey {
+	t, ok := destType(dest)
+	if !ok {
+		return nil
+	}
+	return envKeys(t, "", "", false)
+}
+
+// envKeys returns the EnvKey of the fields of the struct type t.
+func envKeys(t reflect.Type, prefix, path string, secret bool) []EnvKey {
+	var keys []EnvKey
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if skipField(field) || field.Type.Kind() == reflect.Interface {
+			continue
+		}
+		fieldSecret := secret || isSecret(field)
+		if isNested(field.Type) {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			keys = append(keys, envKeys(fieldType, nestedPrefix(field, prefix, defaultNestedSep), path+field.Name+".", fieldSecret)...)
+			continue
+		}
+
+		names := fieldEnvNames(field)
+		key := EnvKey{
+			Path:     path + field.Name,
+			Key:      prefix + names[0],
+			Type:     field.Type.String(),
+			Required: field.Tag.Get("required") == "true",
+			Secret:   fieldSecret,
+		}
+		for _, name := range names[1:] {
+			key.Alternates = append(key.Alternates, prefix+name)
+		}
+		key.Default, key.HasDefault = field.Tag.Lookup("default")
+		if oneOf, ok := field.Tag.Lookup("oneof"); ok {
+			key.OneOf = strings.Fields(oneOf)
+		} else if oneOf, ok := field.Tag.Lookup("oneof_ci"); ok {
+			key.OneOf = strings.Fields(oneOf)
+		}
+		keys = append(keys, key)
+		if isIndexedSlice(field.Type) {
+			elem := field.Type.Elem()
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			keys = append(keys, envKeys(elem, nestedPrefix(field, prefix, defaultNestedSep)+"<n>"+defaultNestedSep, path+field.Name+"[<n>].", fieldSecret)...)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,197 @@
+package lazyenv
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	t.Setenv("MYAPP_PORT", "8080")
+	t.Setenv("MYAPP_PROT", "8080")
+	t.Setenv("MYAPP_DB_HOST", "localhost")
+	t.Setenv("MYAPP_DB_HSOT", "localhost")
+	t.Setenv("MYAPP_SECRET", "skipped")
+	t.Setenv("MYAPP_PASS", "tagged")
+	t.Setenv("OTHER_PORT", "8080")
+	t.Setenv("MYAPP_DATABASE_URL", "postgres://")
+	t.Setenv("MYAPP_SERVERS_0_HOST", "a")
+	t.Setenv("MYAPP_SERVERS_12_HOST", "b")
+	t.Setenv("MYAPP_SERVERS_0_HSOT", "a")
+	t.Setenv("MYAPP_SERVERS_X_HOST", "x")
+
+	type Config struct {
+		Port     int
+		Password string `env:"PASS"`
+		Secret   string `env:"-"`
+		DBURL    string `env:"DB_URL,DATABASE_URL"`
+		DB       *struct {
+			Host string
+		}
+		Servers []struct {
+			Host string
+		}
+	}
+
+	got := UnknownKeys(&Config{}, "MYAPP")
+	expect := []string{"MYAPP_DB_HSOT", "MYAPP_PROT", "MYAPP_SECRET", "MYAPP_SERVERS_0_HSOT", "MYAPP_SERVERS_X_HOST"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("UnknownKeys() = %v; expected %v", got, expect)
+	}
+	if got := UnknownKeys(&Config{}, ""); got != nil {
+		t.Errorf("UnknownKeys() with an empty prefix = %v; expected nil", got)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	keys := []string{"NAME", "DB_HOST", "DB_PASS", "OLD_PORT", "INTERNAL", "OTHER", "DB_SERVERS_0_HOST", "DB_SERVERS_1_HOST", "DB_SERVERS_1_OTHER"}
+	for _, key := range keys {
+		t.Setenv(key, "value")
+	}
+
+	type Config struct {
+		Name     string
+		Port     int    `env:"NEW_PORT,OLD_PORT"`
+		Internal string `env:"-"`
+		DB       struct {
+			Host     string
+			Password string `env:"PASS"`
+			Servers  []*struct {
+				Host string
+			}
+		}
+	}
+	Unset(&Config{})
+
+	for _, key := range []string{"NAME", "DB_HOST", "DB_PASS", "OLD_PORT", "DB_SERVERS_0_HOST", "DB_SERVERS_1_HOST"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Errorf("%s is still set", key)
+		}
+	}
+	for _, key := range []string{"INTERNAL", "OTHER", "DB_SERVERS_1_OTHER"} {
+		if _, ok := os.LookupEnv(key); !ok {
+			t.Errorf("%s was unset", key)
+		}
+	}
+}
+
+func TestKeysInvalidDest(t *testing.T) {
+	t.Setenv("PORT", "8080")
+
+	port := 80
+	for _, dest := range []interface{}{nil, port, &port} {
+		if got := UnknownKeys(dest, "MYAPP"); got != nil {
+			t.Errorf("UnknownKeys(%T) = %v; expected nil", dest, got)
+		}
+		if got := Plan(dest); got != nil {
+			t.Errorf("Plan(%T) = %v; expected nil", dest, got)
+		}
+		if got := Keys(dest); got != nil {
+			t.Errorf("Keys(%T) = %v; expected nil", dest, got)
+		}
+		Unset(dest)
+	}
+	if _, ok := os.LookupEnv("PORT"); !ok {
+		t.Errorf("Unset() removed PORT for an invalid destination")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	t.Setenv("HOST", "example.com")
+	t.Setenv("DB_PORT", "not_a_port")
+
+	type Config struct {
+		Host string
+		Port int `default:"8080"`
+		Name string
+		DB   struct {
+			Port int
+		}
+	}
+
+	config := Config{Host: "initial"}
+	got := Plan(&config)
+
+	expect := []FieldPlan{
+		{Path: "Host", Key: "HOST", Found: true, RawValue: "example.com"},
+		{Path: "Port", Key: "PORT", RawValue: "8080", WillUseDefault: true},
+		{Path: "Name", Key: "NAME"},
+		{Path: "DB.Port", Key: "DB_PORT", Found: true, RawValue: "not_a_port"},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Plan() = %+v; expected %+v", got, expect)
+	}
+	if config.Host != "initial" || config.Port != 0 {
+		t.Errorf("Plan() modified the struct: %+v", config)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	type Database struct {
+		Host     string `default:"localhost"`
+		Password string `required:"true"`
+	}
+	type Config struct {
+		LogLevel string `oneof:"debug info"`
+		URL      string `env:"APP_URL,URL"`
+		Hosts    []string
+		Empty    string `default:""`
+		Skipped  string `env:"-"`
+		Handler  interface{}
+		DB       *Database `env:"DATABASE" secret:"true"`
+		Replicas []*Database
+	}
+
+	expect := []EnvKey{
+		{Path: "LogLevel", Key: "LOG_LEVEL", Type: "string", OneOf: []string{"debug", "info"}},
+		{Path: "URL", Key: "APP_URL", Alternates: []string{"URL"}, Type: "string"},
+		{Path: "Hosts", Key: "HOSTS", Type: "[]string"},
+		{Path: "Empty", Key: "EMPTY", Type: "string", HasDefault: true},
+		{Path: "DB.Host", Key: "DATABASE_HOST", Type: "string", Default: "localhost", HasDefault: true, Secret: true},
+		{Path: "DB.Password", Key: "DATABASE_PASSWORD", Type: "string", Required: true, Secret: true},
+		{Path: "Replicas", Key: "REPLICAS", Type: "[]*lazyenv.Database"},
+		{Path: "Replicas[<n>].Host", Key: "REPLICAS_<n>_HOST", Type: "string", Default: "localhost", HasDefault: true},
+		{Path: "Replicas[<n>].Password", Key: "REPLICAS_<n>_PASSWORD", Type: "string", Required: true},
+	}
+	if got := Keys(&Config{}); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Keys() = %+v; expected %+v", got, expect)
+	}
+}
+
+func TestFillReport(t *testing.T) {
+	type Database struct {
+		Host string
+		Port int `default:"5432"`
+	}
+	type Config struct {
+		AppName  string
+		Debug    bool
+		Workers  int
+		Database Database
+	}
+
+	t.Setenv("APP_NAME", "app")
+	t.Setenv("WORKERS", "many")
+	t.Setenv("DATABASE_HOST", "db")
+	for _, key := range []string{"DEBUG", "DATABASE_PORT"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	var config Config
+	report, err := FillReport(&config)
+
+	expect := Report{
+		Consumed: []string{"APP_NAME", "DATABASE_HOST"},
+		Missing:  []string{"DEBUG", "DATABASE_PORT"},
+	}
+	if !reflect.DeepEqual(report.Consumed, expect.Consumed) || !reflect.DeepEqual(report.Missing, expect.Missing) {
+		t.Errorf("FillReport() = %+v; expected %+v", report, expect)
+	}
+	if err == nil || len(report.Errors) != 1 || report.Errors[0].Key != "WORKERS" {
+		t.Errorf("FillReport() error = %v, report errors = %v; expected the conversion error of WORKERS", err, report.Errors)
+	}
+	if config.AppName != "app" || config.Database.Port != 5432 {
+		t.Errorf("FillReport() filled %+v; expected the values to be set", config)
+	}
+}
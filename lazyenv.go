@@ -2,10 +2,27 @@
 package lazyenv
 
 import (
+	"context"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/term"
 )
@@ -13,22 +30,98 @@ import (
 const (
 	production  string = "production"
 	development string = "development"
+	staging     string = "staging"
+	test        string = "test"
 )
 
-// Env returns Development if the environment is a terminal or the ENVIRONMENT variable starts with "dev".
-// If the environment variable does not starts with dev, it will assume it is development if the output is a terminal.
-// Otherwise it will return Production.
-// Env can only return the strings "development" or "production". If you want to access the environment directly, use os.Getenv("ENVIRONMENT")
+var defaultEnvVarNames = []string{"ENVIRONMENT", "APP_ENV", "GO_ENV"}
+
+var (
+	envMu       sync.RWMutex
+	envVarNames = defaultEnvVarNames
+	envOverride string
+)
+
+// SetEnv forces Env to return env, ignoring the environment variables and the terminal detection.
+// It is meant for tests that need to simulate an environment. An empty env restores the automatic detection.
+func SetEnv(env string) {
+	envMu.Lock()
+	defer envMu.Unlock()
+	envOverride = env
+}
+
+// ResetEnv removes the value set with SetEnv, so Env detects the environment again.
+func ResetEnv() {
+	SetEnv("")
+}
+
+// SetEnvVarNames changes the variables Env reads the environment name from.
+// The first one that is set to a non empty value is used. Calling it without names restores the default list,
+// which is ENVIRONMENT, APP_ENV and GO_ENV.
+func SetEnvVarNames(names ...string) {
+	if len(names) == 0 {
+		names = defaultEnvVarNames
+	}
+	envMu.Lock()
+	defer envMu.Unlock()
+	envVarNames = names
+}
+
+// Env returns Development if stdout or stderr is a terminal or the environment name starts with "dev".
+// The environment name is read from the first non empty variable of ENVIRONMENT, APP_ENV and GO_ENV, in that order.
+// The list can be changed with SetEnvVarNames, and the whole detection can be overridden with SetEnv.
+// If the environment name starts with "stag" it returns Staging, and if it starts with "test" it returns Test.
+// Otherwise, it will assume it is development if stdout or stderr is a terminal.
+// Any other value, including unknown ones, returns Production when neither of them is a terminal.
+// Env can only return the strings "development", "staging", "test" or "production". If you want to access the environment directly, use os.Getenv("ENVIRONMENT")
 func Env() string {
-	isDev := strings.HasPrefix(os.Getenv("ENVIRONMENT"), "dev")
-	if isDev {
-		return development
+	env, _ := EnvReason()
+	return env
+}
+
+// EnvReason returns the same environment as Env, and the reason it was chosen, to debug a surprising detection.
+// The reason is "SetEnv" when it was overridden, the variable and "prefix" when it comes from an environment name,
+// like "ENVIRONMENT prefix", "stdout or stderr is a terminal" for the terminal fallback, or "default" for production.
+func EnvReason() (env string, reason string) {
+	envMu.RLock()
+	override := envOverride
+	envMu.RUnlock()
+	if override != "" {
+		return override, "SetEnv"
 	}
-	if term.IsTerminal(int(os.Stdout.Fd())) {
-		return development
+
+	name, environment := envName()
+	switch {
+	case strings.HasPrefix(environment, "dev"):
+		return development, name + " prefix"
+	case strings.HasPrefix(environment, "stag"):
+		return staging, name + " prefix"
+	case strings.HasPrefix(environment, "test"):
+		return test, name + " prefix"
+	}
+	if isTerminal() {
+		return development, "stdout or stderr is a terminal"
 	}
-	return production
+	return production, "default"
+}
 
+// isTerminal reports whether stdout or stderr is a terminal.
+// stderr is checked too so redirecting the output of an interactive program to a file keeps it in development.
+// It is a variable so tests can stub it.
+var isTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) || term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// envName returns the name and the value of the first environment variable of envVarNames that is not empty.
+func envName() (name, value string) {
+	envMu.RLock()
+	defer envMu.RUnlock()
+	for _, name := range envVarNames {
+		if value := os.Getenv(name); value != "" {
+			return name, value
+		}
+	}
+	return "", ""
 }
 
 // IsProduction returns true if the environment is production
@@ -42,113 +135,1437 @@ func IsDevelopment() bool {
 	return Env() == development
 }
 
+// IsStaging returns true if the environment is staging
+func IsStaging() bool {
+	return Env() == staging
+}
+
+// IsTest returns true if the environment is test
+func IsTest() bool {
+	return Env() == test
+}
+
 // Fill fills the fields of the struct with the values from the environment.
 // It will use the uppercase and dash separated name of the field as the environment variable name.
 // For example, if the struct has a field named "DBName", it will look for the environment variable "DB_NAME".
 // It will try to convert the value to the type of the field.
-// If the field is a pointer, it will try to convert the value to the type of the pointer.
-// If the field is a slice, it will split the value by commas.
+// If the field is a pointer, it will try to convert the value to the type of the pointer, allocating it only when the variable or a default is set.
+// Pointers to pointers, like **int, are allocated at every level.
+// Nil pointers to nested structs are only allocated when at least one of the variables of the struct is set,
+// so an optional section like DB *DBConfig stays nil, and its defaults and required fields are ignored, without DB_* variables.
+// If the field is a slice, it will split the value by commas, except for []byte that gets the raw value.
+// If the field is an array, it will split the value by commas and the number of values must match its length.
 // If the field is a map, it will split the value by commas and then by colons.
+// Pairs are added to the map if it isn't nil, overriding the existing keys.
+// Maps of empty structs, like map[string]struct{}, are sets read from a list of keys like ROLES=admin,user.
+// The separators can be changed with the "sep" and "kvsep" tags.
+// Slices and maps tagged with lines:"true", or with sep:"\n", have an element per line, accepting \r\n line endings.
+// Slices tagged with csv:"true" are read as a CSV record, so their elements can be quoted to contain commas.
+// Fields tagged with trim:"true" have the spaces around the value, and around each slice and map element, removed.
+// If the field is an integer, it accepts underscores like 1_000_000 and the 0x, 0o and 0b prefixes.
+// rune and byte are the same types as int32 and uint8, so integers are parsed as numbers unless they are tagged with char:"true".
+// Tagged fields take a single character instead, like DELIM=|, and are set to its code point.
+// Integers tagged with size:"true" accept byte sizes like 10MB, 2GiB or 0.5GB, converted to bytes.
+// Signed integers also accept negative sizes like -10MB.
+// Floats tagged with percent:"true" accept percentages like 5%, converted to the fraction 0.05.
+// Values without a % are fractions already, so 0.5 is 50%.
+// If the field is a bool, it accepts the values of strconv.ParseBool plus yes/no, on/off and enabled/disabled.
+// Bools tagged with truthy:"int" also accept any integer, where zero is false and the rest are true.
+// A bool set to an empty string, like DEBUG=, is true. The "emptybool" tag can make it false or an error instead.
+// If the field is a time.Duration, it will parse the value with time.ParseDuration,
+// or as an ISO 8601 duration like PT1H30M if it is tagged with iso8601:"true".
+// If the field is a time.Time, it will parse the value as RFC3339 or with the layout of the "layout" tag.
+// If the field is a url.URL, it will parse the value with url.Parse.
+// If the field is a url.Values, it will parse the value as a query string like a=1&b=2&b=3.
+// If the field is a net.IPNet, it will parse the value with net.ParseCIDR and keep the network.
+// If the field implements encoding.TextUnmarshaler, like net.IP, netip.Addr or netip.Prefix, it will use UnmarshalText.
+// Otherwise, if it implements flag.Value, like the custom types of command line flags, it will use Set.
+// A struct implementing encoding.TextUnmarshaler uses UnmarshalText when its own key or default is set,
+// otherwise its fields are filled recursively like any other struct.
+// Values tagged with encoding:"base64" or encoding:"base64url" are decoded before being converted.
+// If the field is a slice or a map of structs, it will decode the value as JSON.
+// When the variable of a slice of structs is not set, its elements are filled from indexed variables instead,
+// like SERVERS_0_HOST and SERVERS_1_HOST for a Servers []Server field. The slice covers the highest index found
+// and the missing indices are zero elements. Only the variables of the fields of the elements count,
+// and indices must be lower than 1024.
 // If the field is a struct, it will recursively fill the fields of the struct using the field name as a prefix.
-// The name of the environment variable can be overridden by the "env" tag in the struct field.
+// If the field is an interface holding a struct or a pointer to a struct, the fields of the concrete value are filled the same way.
+// Nil interfaces, and interfaces holding other types, are skipped.
+// Embedded structs are filled without a prefix, as if their fields were declared in the parent, unless they have an env tag.
+// The name of the environment variable can be overridden by the "env" tag in the struct field, or by another tag set with SetTagKey.
+// The tag can list several names separated by commas, like env:"DB_URL,DATABASE_URL", and the first one that is set is used.
+// Without an env tag, the name in the json tag is used, uppercased and with dashes replaced by underscores.
+// Unexported fields and fields tagged with env:"-", or json:"-" if they have no env tag, are skipped.
+// Variables that are set to an empty string are assigned, while fields of variables that are not set are left untouched.
+// Fields tagged with omitempty:"true" treat empty variables as not set, so they don't clobber values set beforehand.
+// Fields tagged with deprecated, like deprecated:"use NEW_VAR instead", are still filled, but when their variable
+// is set a warning with the key and the message is printed with the logger set with SetLogger.
+// If the environment variable is not set but the same name with a _FILE suffix is, like DB_PASSWORD_FILE,
+// the value is read from the file it names, without its trailing newline, as Docker and Kubernetes pass secrets.
+// The _FILE variables are only read from the process environment, not from the lookups of FillFrom, FillEnviron,
+// FillSources, FillContext or the Lookup and Environ options.
+// If the environment variable is not set, the value of the "default" tag is used instead.
+// A default containing "{{" is a text/template, where {{.Env "NAME"}} is the value of another variable
+// and {{.Hostname}} the host name, like default:"{{.Hostname}}-node". Template errors are reported as field errors.
+// Values and defaults of fields tagged with interpolate:"true" can reference other variables as ${NAME},
+// which are replaced by their values before the conversion.
+// Undefined variables are replaced by an empty string, and "$${" is kept as a literal "${".
+// Other fields keep their values as they are, so passwords and templates containing "${" are not rewritten.
+// Strings tagged with expand:"true" also expand $NAME references, like os.ExpandEnv.
+// Strings tagged with trimprefix or trimsuffix have that prefix or suffix removed if they have it,
+// so trimprefix:"v" reads VERSION=v1.2.3 as 1.2.3.
+// Strings tagged with transform are normalized after being read, with a comma separated list of
+// lower, upper, title and trimspace applied in order, like transform:"trimspace,lower" for emails.
+// Fields are filled in declaration order, nested structs included, so a reference to a variable that is not set
+// gets the default of the field read from it if that field is declared before, like URL interpolate:"true"
+// default:"http://${HOST}:${PORT}" after the fields Host default:"localhost" and Port default:"8080".
+// Numbers tagged with "min" and "max" must be within that inclusive range, like min:"1" max:"65535".
+// Strings tagged with "oneof" must be one of the values it lists separated by spaces, like oneof:"debug info warn error".
+// Use "oneof_ci" instead to compare them ignoring case.
+// Values that can't be converted are ignored. Use FillE to know about them.
+// dest must be a non-nil pointer to a struct, otherwise Fill panics with an error saying so.
+// Use FillKey and FillMap to fill a single value, like a slice, or a map that isn't part of a struct.
 func Fill(dest interface{}) {
-	fillWithPrefix(dest, "")
+	panicOnInvalidDest(FillWithOptions(dest, Options{}))
+}
+
+// FillFrom works like Fill but reads the values with lookup instead of from the environment.
+// lookup returns the value of the key and whether it was set, like os.LookupEnv.
+func FillFrom(dest interface{}, lookup func(key string) (string, bool)) {
+	panicOnInvalidDest(FillWithOptions(dest, Options{Lookup: lookup}))
+}
+
+// FillSources works like FillFrom but reads each key from several sources, like a map of overrides, the values of
+// a .env file and os.LookupEnv. The first source that has the key set wins, so the sources go from most to least important.
+func FillSources(dest interface{}, sources ...func(key string) (string, bool)) {
+	FillFrom(dest, sourcesLookup(sources))
+}
+
+// sourcesLookup returns a lookup function that returns the value of the first of sources that has the key set.
+func sourcesLookup(sources []func(key string) (string, bool)) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		for _, source := range sources {
+			if value, ok := source(key); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+}
+
+// FillCaseInsensitive works like Fill but matches the names of the environment variables ignoring case,
+// so DB_NAME, db_name and Db_Name all fill the field DBName.
+// The environment is read once. If several variables differ only in case, the last one in os.Environ() wins.
+func FillCaseInsensitive(dest interface{}) {
+	panicOnInvalidDest(FillWithOptions(dest, Options{CaseInsensitive: true}))
+}
+
+// caseInsensitiveLookup reads the environment once and returns a lookup function that ignores the case of the keys.
+func caseInsensitiveLookup() func(key string) (string, bool) {
+	env := map[string]string{}
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		env[strings.ToUpper(key)] = value
+	}
+	return func(key string) (string, bool) {
+		value, ok := env[strings.ToUpper(key)]
+		return value, ok
+	}
+}
+
+// FillEnviron works like Fill but reads the values from environ instead of the process environment.
+// environ has the format of os.Environ and exec.Cmd.Env, a list of KEY=value entries.
+// If a key appears more than once the last value is used, like the operating system does.
+func FillEnviron(dest interface{}, environ []string) {
+	panicOnInvalidDest(FillWithOptions(dest, Options{Environ: environ}))
+}
+
+// environMap returns the values of a list of KEY=value entries, like os.Environ, keyed by name.
+func environMap(environ []string) map[string]string {
+	values := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// mapLookup returns a lookup function that reads the values from a map.
+func mapLookup(values map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+// FillPrefixed works like Fill but prepends prefix to the names of all the environment variables.
+// A "_" is added between the prefix and the names, so the field DBHost with prefix "MYAPP" reads MYAPP_DB_HOST.
+// An empty prefix behaves like Fill.
+func FillPrefixed(dest interface{}, prefix string) {
+	panicOnInvalidDest(FillWithOptions(dest, Options{Prefix: prefix}))
+}
+
+// FillSection fills dest as the section of a bigger configuration found at the dotted path of fields section,
+// reading the same variables it would get as that field of the full configuration.
+// Unlike the prefix of FillPrefixed, the names of the path are converted like the names of the fields, so
+// FillSection(&db, "Database") reads DATABASE_HOST and FillSection(&pool, "DB.Pool") reads DB_POOL_SIZE.
+// It is useful for tools that only need part of the configuration. The env tags of the fields of the path
+// are not known, so those sections should be filled with FillPrefixed and the names of their tags instead.
+func FillSection(dest interface{}, section string) {
+	var prefix string
+	if section != "" {
+		for _, name := range strings.Split(section, ".") {
+			prefix += convertName(name) + defaultNestedSep
+		}
+	}
+	FillPrefixed(dest, prefix)
+}
+
+// FillRemainder returns all the environment variables starting with prefix, keyed by the rest of their name.
+// It is useful for settings that aren't known in advance: with the prefix "PLUGIN",
+// PLUGIN_FOO=1 and PLUGIN_BAR=2 return {"FOO": "1", "BAR": "2"}.
+// The prefix is handled like in FillPrefixed, but it can't be empty.
+func FillRemainder(prefix string) map[string]string {
+	prefix = keyPrefix(prefix)
+	result := map[string]string{}
+	if prefix == "" {
+		return result
+	}
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// FillKey fills dest, a pointer to a value that is not a struct, from the single environment variable key.
+// The value is converted with the same rules as the fields of Fill, so a []string is split by commas
+// and a map[string]bool is read from pairs like beta:true,dark_mode:false. A map that isn't nil is merged into.
+// dest is left untouched if key is not set. The returned error is of type Errors, like in FillE.
+func FillKey(dest interface{}, key string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return &invalidDestError{function: "FillKey", expected: "a value", dest: dest}
+	}
+	f := Options{}.filler()
+	if envValue, ok := f.lookup(key); ok {
+		f.fillValue(v.Elem(), "", key, envValue)
+	}
+	if len(f.errs) > 0 {
+		return f.errs
+	}
+	return nil
+}
+
+// FillMap fills dest, a pointer to a map, with all the environment variables starting with prefix.
+// The keys of the map are the rest of the names of the variables, like in FillRemainder,
+// and both keys and values are converted to the types of the map with the same rules as the fields of Fill.
+// For example, with the prefix "FEATURE", FEATURE_BETA=true fills the key "BETA" of a map[string]bool.
+// The variables that can't be converted are left out and reported in the returned error of type Errors.
+// A map that isn't nil is merged into. The prefix can't be empty.
+func FillMap(dest interface{}, prefix string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Map {
+		return &invalidDestError{function: "FillMap", expected: "a map", dest: dest}
+	}
+	mapValue := v.Elem()
+	if mapValue.IsNil() {
+		mapValue.Set(reflect.MakeMap(mapValue.Type()))
+	}
+
+	f := Options{}.filler()
+	remainder := FillRemainder(prefix)
+	names := make([]string, 0, len(remainder))
+	for name := range remainder {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		envKey := keyPrefix(prefix) + name
+		key := reflect.New(mapValue.Type().Key()).Elem()
+		if err := f.setFieldValue(key, name, ""); err != nil {
+			f.failAs(ErrConvert, "", envKey, remainder[name], key.Kind(), fmt.Errorf("invalid key %q: %w", name, err))
+			continue
+		}
+		value := reflect.New(mapValue.Type().Elem()).Elem()
+		if f.fillValue(value, "", envKey, remainder[name]) {
+			mapValue.SetMapIndex(key, value)
+		}
+	}
+	if len(f.errs) > 0 {
+		return f.errs
+	}
+	return nil
+}
+
+// FillMissing works like Fill but only fills the fields that have their zero value,
+// leaving the fields that are already set untouched.
+// Nested structs are always visited, so their unset fields are filled too.
+func FillMissing(dest interface{}) {
+	panicOnInvalidDest(FillWithOptions(dest, Options{MissingOnly: true}))
 }
 
-func fillWithPrefix(dest interface{}, prefix string) {
-	v := reflect.ValueOf(dest).Elem()
+// Source tells where the value of a field comes from.
+type Source int
+
+const (
+	SourceEnv     Source = iota // The environment variable was set
+	SourceDefault               // The environment variable was not set and the default tag was used
+	SourceUnset                 // The environment variable was not set and there was no default
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceEnv:
+		return "env"
+	case SourceDefault:
+		return "default"
+	case SourceUnset:
+		return "unset"
+	}
+	return "Source(" + strconv.Itoa(int(s)) + ")"
+}
+
+// FillWithHook works like Fill but calls hook for every field that is not a nested struct,
+// with the dotted path of the field, the environment variable it is read from, its raw value and its source.
+// It is useful to log the configuration as it is loaded.
+// The values of fields tagged with secret:"true", or inside a struct tagged with it, are replaced by "***".
+func FillWithHook(dest interface{}, hook func(fieldPath, envKey, rawValue string, source Source)) {
+	panicOnInvalidDest(FillWithOptions(dest, Options{Hook: hook}))
+}
+
+// FillE works like Fill but returns an error if any of the values can't be converted to the type of its field,
+// if a field tagged with required:"true" has no variable set and no default,
+// or if a value is outside the range of its "min" and "max" tags or isn't one of the values of its "oneof" tag.
+// Fields that are alternatives to each other can share a "group" tag, like group:"dbsource" on a DatabaseURL field
+// and on a struct with its parts, and exactly one of them must have its variables set.
+// In FillJSON, a field that already has a value from the JSON document counts as set too.
+// After a struct is filled, its Validate() error method is called if it has one, and the error is returned too.
+// Nested structs are validated before their parents.
+// The returned error is of type Errors and contains a *FieldError for each failure. The kind of failure can be
+// checked with errors.Is and ErrRequired, ErrConvert or ErrValidate, and the field with errors.As and a *FieldError.
+// All the fields that could be converted are filled even if an error is returned.
+// If dest is not a non-nil pointer to a struct, FillE returns an error saying so and doesn't fill anything.
+func FillE(dest interface{}) error {
+	return FillWithOptions(dest, Options{})
+}
+
+// FillStrict works like FillE but is stricter with the variables that are set:
+// any value that can't be fully converted to its field is an error.
+// On top of the errors of FillE, it reports map pairs without a key separator, references to undefined variables,
+// and values set for fields of types that can't be read from the environment, like channels or functions.
+// Missing variables are still fine unless the field is required.
+func FillStrict(dest interface{}) error {
+	return FillWithOptions(dest, Options{Strict: true})
+}
+
+// FillJSON fills dest from a JSON document stored in the environment variable key, and then from the
+// individual environment variables like FillE. The JSON document is the base and the variables override it:
+// a field is only set from its default tag, or reported as required, if it is still zero after decoding the JSON.
+// If key is not set, FillJSON behaves like FillE.
+func FillJSON(dest interface{}, key string) error {
+	if data, ok := os.LookupEnv(key); ok {
+		if err := json.Unmarshal([]byte(data), dest); err != nil {
+			return Errors{{Key: key, Value: data, Kind: reflect.Struct, Err: err, category: ErrConvert}}
+		}
+	}
+	return FillWithOptions(dest, Options{Prefilled: true})
+}
+
+// MustFill works like FillE but panics if there is any error.
+// The panic value is the error returned by FillE, so it can be inspected after recovering.
+func MustFill(dest interface{}) {
+	if err := FillE(dest); err != nil {
+		panic(err)
+	}
+}
+
+// Options configures how FillWithOptions fills a struct. The zero value behaves like FillE.
+type Options struct {
+	// Prefix is prepended to the names of all the environment variables, like in FillPrefixed.
+	Prefix string
+	// Lookup reads the values instead of os.LookupEnv, like in FillFrom.
+	Lookup func(key string) (string, bool)
+	// Environ has the variables to read instead of the process environment, as KEY=value entries like in FillEnviron.
+	// It can't be used together with Lookup or CaseInsensitive.
+	Environ []string
+	// CaseInsensitive matches the names of the environment variables ignoring case, like in FillCaseInsensitive.
+	// It reads the process environment, so it can't be used together with Lookup.
+	CaseInsensitive bool
+	// Strict reports the values that can't be fully converted to their field, like in FillStrict.
+	Strict bool
+	// SliceSep is the separator of slice elements and map pairs for the fields without a "sep" tag. It defaults to ",".
+	SliceSep string
+	// MapSep is the separator of map keys and values for the fields without a "kvsep" tag. It defaults to ":".
+	MapSep string
+	// NestedSep separates the names of nested structs, and the indices of slices of structs, from the names
+	// of their fields, like "__" for DB__HOST or "." for DB.HOST. It defaults to "_".
+	// Prefix is still joined with "_" when it doesn't end with one. The functions that inspect a struct without
+	// options, Keys, UnknownKeys, Unset, Plan, Diff, FillReport and Dump, always use "_".
+	NestedSep string
+	// MissingOnly only fills the fields that have their zero value, like in FillMissing.
+	MissingOnly bool
+	// Prefilled is for a dest that already has values from another source, like the JSON document of FillJSON.
+	// The variables still override the values, but defaults and required fields only apply to the fields that are zero.
+	Prefilled bool
+	// Hook is called for every field that is not a nested struct, like in FillWithHook.
+	Hook func(fieldPath, envKey, rawValue string, source Source)
+}
+
+// FillContext fills dest like FillE, reading the variables with lookup, which gets ctx.
+// It is meant for lookups that call remote services, like secret managers, that could be slow or hang.
+// If ctx is done, or lookup returns an error, the fill stops and the error is returned. For a done ctx it is ctx.Err().
+// The fields filled before the fill stopped keep their values, the rest are left untouched and no Validate method is called.
+func FillContext(ctx context.Context, dest interface{}, lookup func(ctx context.Context, key string) (string, bool, error)) error {
+	var f *filler
+	f = Options{Lookup: func(key string) (string, bool) {
+		if f.abortErr != nil {
+			return "", false
+		}
+		if err := ctx.Err(); err != nil {
+			f.abortErr = err
+			return "", false
+		}
+		value, ok, err := lookup(ctx, key)
+		if err != nil {
+			f.abortErr = fmt.Errorf("lazyenv: lookup %s: %w", key, err)
+			return "", false
+		}
+		return value, ok
+	}}.filler()
+
+	err := f.fill(dest, "")
+	if ctxErr := ctx.Err(); ctxErr != nil && f.abortErr != nil {
+		return ctxErr
+	}
+	if f.abortErr != nil {
+		return f.abortErr
+	}
+	return err
+}
+
+// FillValue fills the struct v like FillE with the given prefix, like FillPrefixed, for code that already has
+// a reflect.Value, like frameworks, so it doesn't need to go through an interface{}.
+// v must be a struct that can be set, like the Elem of a pointer or a field of such a struct, otherwise an error is returned.
+func FillValue(v reflect.Value, prefix string) error {
+	if v.Kind() != reflect.Struct || !v.CanSet() {
+		got := "an invalid value"
+		if v.IsValid() {
+			got = v.Type().String()
+			if v.Kind() == reflect.Struct {
+				got = "a struct that can't be set"
+			}
+		}
+		return fmt.Errorf("lazyenv: FillValue requires an addressable struct value, got %s", got)
+	}
+	return Options{}.filler().fillStruct(v, prefix)
+}
+
+// FillWithOptions fills dest like FillE, configured by opts.
+// The other Fill functions of a struct are shortcuts for a set of options, except FillContext and FillValue,
+// which take their own kind of lookup and destination.
+func FillWithOptions(dest interface{}, opts Options) error {
+	if opts.CaseInsensitive && opts.Lookup != nil {
+		return errors.New("lazyenv: the CaseInsensitive and Lookup options can't be used together")
+	}
+	if opts.Environ != nil && (opts.CaseInsensitive || opts.Lookup != nil) {
+		return errors.New("lazyenv: the Environ option can't be used together with CaseInsensitive or Lookup")
+	}
+	return opts.filler().fill(dest, opts.Prefix)
+}
+
+// filler returns a filler configured with the options.
+func (opts Options) filler() *filler {
+	lookup := opts.Lookup
+	var environ func() []string
+	switch {
+	case opts.Environ != nil:
+		values := environMap(opts.Environ)
+		lookup = mapLookup(values)
+		environ = func() []string {
+			return slices.Collect(maps.Keys(values))
+		}
+	case opts.CaseInsensitive:
+		lookup = caseInsensitiveLookup()
+	case lookup == nil:
+		lookup = os.LookupEnv
+		environ = environNames
+	}
+	return &filler{
+		lookup:      lookup,
+		environ:     environ,
+		files:       opts.Lookup == nil && opts.Environ == nil,
+		missingOnly: opts.MissingOnly,
+		hook:        opts.Hook,
+		strict:      opts.Strict,
+		prefilled:   opts.Prefilled,
+		sep:         opts.SliceSep,
+		kvsep:       opts.MapSep,
+		nestedSep:   opts.NestedSep,
+	}
+}
+
+// filler holds the state of a single fill operation.
+type filler struct {
+	lookup      func(key string) (string, bool)
+	environ     func() []string // Names of the variables that lookup reads, nil if they can't be listed
+	files       bool            // Read the values of the _FILE variables from their files, only for the process environment
+	missingOnly bool            // Only fill fields that have the zero value
+	hook        func(fieldPath, envKey, rawValue string, source Source)
+	inspect     func(fieldPath, envKey string, source Source, secret bool) // Called like hook, with whether the field is secret
+	strict      bool                                                       // Report malformed map pairs, undefined references and values for unsupported types
+	prefilled   bool                                                       // dest already has values from another source, only use defaults for zero fields
+	sep         string                                                     // Separator of slice elements for fields without a sep tag
+	kvsep       string                                                     // Separator of map keys and values for fields without a kvsep tag
+	nestedSep   string                                                     // Separator of the names of nested structs and their fields, "_" if empty
+	resolved    map[string]string                                          // Interpolated values of the fields filled so far, by key
+	abortErr    error                                                      // Error that stopped the fill, like the cancellation of the context of FillContext
+	quiet       bool                                                       // Don't print warnings, for the functions that only inspect the configuration
+	errs        Errors
+}
+
+func (f *filler) fill(dest interface{}, prefix string) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return &invalidDestError{function: "Fill", expected: "a struct", dest: dest}
+	}
+	return f.fillStruct(v.Elem(), prefix)
+}
+
+// fillStruct fills the settable struct v, with prefix prepended to the keys, and returns the errors found.
+func (f *filler) fillStruct(v reflect.Value, prefix string) error {
+	f.fillWithPrefix(v, keyPrefix(prefix), "", false)
+	if len(f.errs) > 0 {
+		return f.errs
+	}
+	return nil
+}
+
+// panicOnInvalidDest panics if err is an invalidDestError. The functions that don't return errors use it,
+// as an invalid destination is a bug in the caller that shouldn't be ignored like the conversion errors.
+func panicOnInvalidDest(err error) {
+	var destErr *invalidDestError
+	if errors.As(err, &destErr) {
+		panic(err)
+	}
+}
+
+// keyPrefix returns the prefix prepended to the keys, adding the "_" separator if needed.
+func keyPrefix(prefix string) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	return prefix
+}
+
+// lookupFile reads the value of a field from a file when its variable is not set but the same name with
+// a _FILE suffix is, like DB_PASSWORD_FILE=/run/secrets/db_password. A trailing newline of the file is removed.
+// The alternate names of the field are checked in order, and key is the _FILE variable used.
+// Only the process environment is checked, so a custom lookup, like a remote secret store, can't make the
+// program read local files, and the keys are not looked up twice in it.
+func (f *filler) lookupFile(prefix string, field reflect.StructField) (key, value string, found bool, err error) {
+	if !f.files {
+		return "", "", false, nil
+	}
+	for _, name := range fieldEnvNames(field) {
+		key = prefix + name + "_FILE"
+		path, ok := f.lookup(key)
+		if !ok {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return key, "", false, err
+		}
+		value = strings.TrimSuffix(string(content), "\n")
+		return key, strings.TrimSuffix(value, "\r"), true, nil
+	}
+	return "", "", false, nil
+}
+
+// resolve records the interpolated value of the field read from key, so the next fields can reference it.
+func (f *filler) resolve(key, value string) {
+	if f.resolved == nil {
+		f.resolved = map[string]string{}
+	}
+	f.resolved[key] = value
+}
+
+// failAs records an error that also matches category, ErrConvert or ErrValidate, with errors.Is.
+// The errors of required fields wrap ErrRequired themselves, so they don't need a category.
+func (f *filler) failAs(category error, path, key, value string, kind reflect.Kind, err error) {
+	f.errs = append(f.errs, &FieldError{
+		Path:     path,
+		Key:      key,
+		Value:    value,
+		Kind:     kind,
+		Err:      err,
+		category: category,
+	})
+}
+
+// fillWithPrefix fills the fields of the struct v. secret is true when v is inside a field tagged with secret:"true".
+// The fields are filled in declaration order, and nested structs are filled completely before the next field.
+func (f *filler) fillWithPrefix(v reflect.Value, prefix, path string, secret bool) {
 	t := v.Type()
+	preset := f.presetFields(v)
 
 	for i := 0; i < t.NumField(); i++ {
+		if f.abortErr != nil {
+			return
+		}
 		field := t.Field(i)
+		if skipField(field) {
+			continue
+		}
 		fieldValue := v.Field(i)
-
-		envName := field.Tag.Get("env")
-		if envName == "" {
-			envName = toEnvName(field.Name)
+		fieldPath := path + field.Name
+		if fieldValue.Kind() == reflect.Interface {
+			f.fillInterface(fieldValue, nestedPrefix(field, prefix, f.nestedSeparator()), fieldPath+".", secret || isSecret(field))
+			continue
 		}
-		envKey := prefix + envName
-
-		envValue := os.Getenv(envKey)
-		if envValue != "" {
-			setFieldValue(fieldValue, envValue)
+		if f.missingOnly && !isNested(fieldValue.Type()) && !fieldValue.IsZero() {
+			continue
 		}
 
-		// Check if the field is a struct or a pointer to a struct
-		if fieldValue.Kind() == reflect.Struct || (fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct) {
+		envName := fieldEnvName(field)
+		envKey := prefix + envName
+		fieldSecret := secret || isSecret(field)
+
+		if isNested(fieldValue.Type()) {
 			if fieldValue.Kind() == reflect.Ptr {
 				if fieldValue.IsNil() {
+					if !f.hasStructKey(fieldValue.Type(), nestedPrefix(field, prefix, f.nestedSeparator())) {
+						// None of the variables of the struct are set, so it stays nil instead of only getting defaults
+						continue
+					}
 					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
 				}
 				fieldValue = fieldValue.Elem()
 			}
-			fillWithPrefix(fieldValue.Addr().Interface(), prefix+envName+"_")
+			f.fillWithPrefix(fieldValue, nestedPrefix(field, prefix, f.nestedSeparator()), fieldPath+".", fieldSecret)
+			continue
+		}
+
+		source := SourceEnv
+		var envValue string
+		var found bool
+		omitEmpty := field.Tag.Get("omitempty") == "true"
+		for _, name := range fieldEnvNames(field) {
+			if envValue, found = f.lookup(prefix + name); found && envValue == "" && omitEmpty {
+				// Empty values are treated as if the variable was not set
+				found = false
+			}
+			if found {
+				envKey = prefix + name
+				break
+			}
+		}
+		if !found {
+			fileKey, fileValue, ok, err := f.lookupFile(prefix, field)
+			if err != nil {
+				f.failAs(ErrConvert, fieldPath, fileKey, "", fieldValue.Kind(), err)
+				continue
+			}
+			if ok {
+				envKey, envValue, found = fileKey, fileValue, true
+			}
+		}
+		if f.abortErr != nil {
+			return
+		}
+		if !found && f.prefilled && !fieldValue.IsZero() {
+			// Keep the value from the other source instead of using the default
+			continue
+		}
+		if !found && isIndexedSlice(fieldValue.Type()) && f.fillIndexed(fieldValue, nestedPrefix(field, prefix, f.nestedSeparator()), fieldPath, fieldSecret) {
+			continue
+		}
+		if !found {
+			source = SourceDefault
+			envValue, found = field.Tag.Lookup("default")
+			if found && isTemplate(envValue) {
+				value, err := f.executeDefault(envValue)
+				if err != nil {
+					f.failAs(ErrConvert, fieldPath, envKey, envValue, fieldValue.Kind(), fmt.Errorf("invalid default: %w", err))
+					continue
+				}
+				envValue = value
+			}
+		}
+		if !found && isTextStruct(fieldValue.Type()) {
+			// The struct isn't set as a whole, so its fields are filled one by one instead
+			f.fillWithPrefix(fieldValue, nestedPrefix(field, prefix, f.nestedSeparator()), fieldPath+".", fieldSecret)
+			continue
+		}
+		if !found {
+			source = SourceUnset
+		}
+		if message, ok := field.Tag.Lookup("deprecated"); ok && source == SourceEnv && !f.quiet {
+			warn("lazyenv: %s is deprecated: %s", envKey, message)
+		}
+		if f.hook != nil {
+			rawValue := envValue
+			if found && fieldSecret {
+				rawValue = secretMask
+			}
+			f.hook(fieldPath, envKey, rawValue, source)
+		}
+		if f.inspect != nil {
+			f.inspect(fieldPath, envKey, source, fieldSecret)
+		}
+
+		if found {
+			var visiting []string
+			if source == SourceEnv {
+				visiting = []string{envKey}
+			}
+			f.fillField(fieldValue, field.Tag, fieldPath, envKey, envValue, visiting)
+		} else if field.Tag.Get("required") == "true" {
+			f.failAs(nil, fieldPath, envKey, "", fieldValue.Kind(), ErrRequired)
+		}
+	}
+
+	if f.abortErr == nil {
+		f.checkGroups(t, prefix, path, preset)
+	}
+	if !v.CanInterface() || f.abortErr != nil {
+		// Unexported embedded struct, its Validate method is promoted to the parent anyway,
+		// or a fill that was aborted, which would validate a partial struct
+		return
+	}
+	if validator, ok := v.Addr().Interface().(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			f.failAs(ErrValidate, strings.TrimSuffix(path, "."), "", "", reflect.Struct, err)
 		}
 	}
 }
 
+// fillInterface fills the struct held by the interface v, which can be a struct or a pointer to a struct.
+// Interfaces that are nil, or that hold any other type, are left untouched.
+func (f *filler) fillInterface(v reflect.Value, prefix, path string, secret bool) {
+	if v.IsNil() || !isNested(v.Elem().Type()) {
+		return
+	}
+	concrete := v.Elem()
+	if concrete.Kind() == reflect.Ptr {
+		if !concrete.IsNil() {
+			f.fillWithPrefix(concrete.Elem(), prefix, path, secret)
+		}
+		return
+	}
+	// The value held by an interface can't be modified, so a copy is filled and stored back
+	copied := reflect.New(concrete.Type()).Elem()
+	copied.Set(concrete)
+	f.fillWithPrefix(copied, prefix, path, secret)
+	v.Set(copied)
+}
+
+// fillValue sets v to envValue, the value of the variable envKey, reporting the errors with path.
+// It returns whether v was set.
+func (f *filler) fillValue(v reflect.Value, path, envKey, envValue string) bool {
+	return f.fillField(v, "", path, envKey, envValue, []string{envKey})
+}
+
+// fillField interpolates, if tag has interpolate:"true", decodes, converts and checks envValue and sets it to fieldValue,
+// reporting the errors with path and envKey. visiting are the variables being interpolated.
+// It returns whether fieldValue was set.
+func (f *filler) fillField(fieldValue reflect.Value, tag reflect.StructTag, path, envKey, envValue string, visiting []string) bool {
+	value := envValue
+	var err error
+	if tag.Get("interpolate") == "true" {
+		value, err = f.interpolate(envValue, visiting)
+	}
+	if f.abortErr != nil {
+		return false
+	}
+	if err == nil {
+		f.resolve(envKey, value)
+		value, err = decodeValue(value, tag)
+	}
+	if err == nil {
+		err = f.setFieldValue(fieldValue, value, tag)
+	}
+	if err != nil {
+		f.failAs(ErrConvert, path, envKey, envValue, fieldValue.Kind(),
+			fmt.Errorf("cannot convert %q to %s: %w", envValue, fieldValue.Type(), err))
+		return false
+	}
+	if err := f.checkValue(fieldValue, tag); err != nil {
+		f.failAs(ErrValidate, path, envKey, envValue, fieldValue.Kind(), err)
+		return false
+	}
+	return true
+}
+
+// skipField reports whether field should not be read from the environment.
+// Unexported fields are always skipped, as they can't be set,
+// except embedded structs whose exported fields can still be set.
+func skipField(field reflect.StructField) bool {
+	if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+		return true
+	}
+	if tag, ok := envTag(field); ok {
+		return tag == "-"
+	}
+	return field.Tag.Get("json") == "-"
+}
+
+// defaultTagKey is the key of the struct tag with the names of the environment variables.
+const defaultTagKey = "env"
+
+var (
+	tagKeyMu sync.RWMutex
+	tagKey   = defaultTagKey
+)
+
+// SetTagKey changes the key of the struct tag read instead of env, for code that already uses another tag,
+// like conf:"PORT". The tag works exactly like env, including "-" to skip a field. The json tag is still used
+// for the fields without it. An empty key restores the default. The code generated by lazyenvgen always uses env.
+func SetTagKey(key string) {
+	if key == "" {
+		key = defaultTagKey
+	}
+	tagKeyMu.Lock()
+	defer tagKeyMu.Unlock()
+	tagKey = key
+}
+
+// envTag returns the value of the tag with the names of the environment variables of field, env by default,
+// and whether it is present.
+func envTag(field reflect.StructField) (string, bool) {
+	tagKeyMu.RLock()
+	key := tagKey
+	tagKeyMu.RUnlock()
+	return field.Tag.Lookup(key)
+}
+
+// defaultNestedSep separates the names of nested structs from the names of their fields, as in DB_HOST.
+const defaultNestedSep = "_"
+
+// nestedPrefix returns the prefix of the fields of a nested struct field, joining the name of the field with sep.
+// Embedded structs don't add their name to the prefix unless they have an env tag.
+func nestedPrefix(field reflect.StructField, prefix, sep string) string {
+	if tag, _ := envTag(field); field.Anonymous && tag == "" {
+		return prefix
+	}
+	return prefix + fieldEnvName(field) + sep
+}
+
+// nestedSeparator returns the separator of the names of nested structs, set with the NestedSep option.
+func (f *filler) nestedSeparator() string {
+	if f.nestedSep == "" {
+		return defaultNestedSep
+	}
+	return f.nestedSep
+}
+
+// secretMask replaces the values of secret fields in Dump and FillWithHook.
+const secretMask = "***"
+
+// isSecret reports whether field is tagged with secret:"true".
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true"
+}
+
+// fieldEnvName returns the name of the environment variable of a field, without any prefix.
+// When the env tag lists several names, the first one is the canonical name returned.
+func fieldEnvName(field reflect.StructField) string {
+	return fieldEnvNames(field)[0]
+}
+
+// fieldEnvNames returns all the names the environment variable of a field can have, in order of preference.
+// The names come from the env tag, then from the name in the json tag, and finally from the field name.
+func fieldEnvNames(field reflect.StructField) []string {
+	if tag, _ := envTag(field); tag != "" {
+		return strings.Split(tag, ",")
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return []string{strings.ToUpper(strings.ReplaceAll(name, "-", "_"))}
+	}
+	return []string{convertName(field.Name)}
+}
+
+// isNested reports whether t is a struct, or a pointer to a struct, whose fields should be filled recursively.
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isValueType(t)
+}
+
+// isSet reports whether t is a map of empty structs, like map[string]struct{}, used as a set of its keys.
+func isSet(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+}
+
+// isValueType reports whether t is read from a single value even if it is a struct,
+// like time.Time, url.URL, net.IPNet, or any type implementing encoding.TextUnmarshaler or flag.Value.
+func isValueType(t reflect.Type) bool {
+	return t == timeType || t == urlType || t == ipNetType ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(flagValueType)
+}
+
+// isTextStruct reports whether t is a struct that implements encoding.TextUnmarshaler and also has fields of its own.
+// Such a struct is read from a single value when its key, or its default, is set.
+// Otherwise its fields are filled recursively like any other nested struct.
+func isTextStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != urlType && t != ipNetType &&
+		reflect.PointerTo(t).Implements(textUnmarshalerType) && len(structKeys(t, "", defaultNestedSep, false)) > 0
+}
+
+var (
+	loggerMu sync.RWMutex
+	logger   = log.Printf
+)
+
+// SetLogger changes the function used to print warnings, like the ones about deprecated variables.
+// The default is log.Printf, which writes to stderr. Calling it with nil restores the default.
+func SetLogger(printf func(format string, v ...interface{})) {
+	if printf == nil {
+		printf = log.Printf
+	}
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = printf
+}
+
+// warn prints a warning with the logger set with SetLogger.
+func warn(format string, v ...interface{}) {
+	loggerMu.RLock()
+	printf := logger
+	loggerMu.RUnlock()
+	printf(format, v...)
+}
+
+var (
+	nameMu       sync.RWMutex
+	nameStrategy = ScreamingSnakeCase
+)
+
+// SetNameStrategy changes how the names of the fields without an env or json tag are converted to
+// environment variable names, for Fill and all the functions that read or describe structs, like Dump and Keys.
+// The package provides ScreamingSnakeCase, the default, UpperCase and LowerSnakeCase, but any function can be used.
+// Calling it with nil restores the default. The code generated by lazyenvgen always uses the default.
+func SetNameStrategy(strategy func(string) string) {
+	if strategy == nil {
+		strategy = ScreamingSnakeCase
+	}
+	nameMu.Lock()
+	defer nameMu.Unlock()
+	nameStrategy = strategy
+}
+
+// convertName converts a field name to an environment variable name with the strategy set with SetNameStrategy.
+func convertName(name string) string {
+	nameMu.RLock()
+	strategy := nameStrategy
+	nameMu.RUnlock()
+	return strategy(name)
+}
+
+// ScreamingSnakeCase converts a field name to an uppercase name with words separated by "_", so DBName becomes DB_NAME.
+// It is the default name strategy.
+func ScreamingSnakeCase(name string) string {
+	return toEnvName(name)
+}
+
+// UpperCase converts a field name to uppercase without separating words, so DBName becomes DBNAME.
+func UpperCase(name string) string {
+	return strings.ToUpper(name)
+}
+
+// LowerSnakeCase converts a field name to a lowercase name with words separated by "_", so DBName becomes db_name.
+func LowerSnakeCase(name string) string {
+	return strings.ToLower(toEnvName(name))
+}
+
 // toEnvName converts a field name to an environment variable name.
+// Words are separated with "_" when a lowercase letter is followed by an uppercase one (MyVar),
+// at the end of an acronym of two or more letters (HTTPServer), and after digits (S3Bucket).
+// Digits stay with the word before them, and a single capital letter stays with the word after it when it
+// starts the name, so OAuth2Token becomes OAUTH2_TOKEN and GetAValue becomes GET_A_VALUE.
+// This breaks names starting with a single capital letter, which used to be split after it: XAxis read X_AXIS
+// and now reads XAXIS. Set the env tag to keep the old name.
 func toEnvName(name string) string {
-	var result string
-	for i, r := range name {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			if name[i-1] >= 'a' && name[i-1] <= 'z' || (i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z') {
-				result += "_"
-			}
+	runes := []rune(name)
+	var result strings.Builder
+	for i, r := range runes {
+		if i > 0 && isWordBoundary(runes, i) {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return result.String()
+}
+
+// isWordBoundary reports whether a new word starts at runes[i].
+func isWordBoundary(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+	switch {
+	case unicode.IsDigit(prev):
+		return unicode.IsLetter(r)
+	case unicode.IsLower(prev):
+		return unicode.IsUpper(r)
+	case unicode.IsUpper(prev):
+		return unicode.IsUpper(r) && i >= 2 && runes[i-2] != '_' && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+	}
+	return false
+}
+
+// sliceSep returns the separator of slice elements and map pairs, set with the "sep" tag.
+// Fields tagged with lines:"true" have one element per line.
+func sliceSep(tag reflect.StructTag) string {
+	if sep := tag.Get("sep"); sep != "" {
+		return sep
+	}
+	if tag.Get("lines") == "true" {
+		return "\n"
+	}
+	return ","
+}
+
+// sliceSep returns the separator of slice elements and map pairs, from the "sep" tag or the SliceSep option.
+func (f *filler) sliceSep(tag reflect.StructTag) string {
+	if tag.Get("sep") == "" && tag.Get("lines") != "true" && f.sep != "" {
+		return f.sep
+	}
+	return sliceSep(tag)
+}
+
+// splitLines splits a multi-line value into its lines. Windows line endings are accepted
+// and the empty lines at the end are dropped, so a trailing newline doesn't add an empty element.
+func splitLines(value string) []string {
+	value = strings.TrimRight(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, "\n")
+}
+
+// transform applies the comma separated list of transforms of the "transform" tag to value, in order.
+func transform(value, transforms string) (string, error) {
+	for _, name := range strings.Split(transforms, ",") {
+		switch strings.TrimSpace(name) {
+		case "lower":
+			value = strings.ToLower(value)
+		case "upper":
+			value = strings.ToUpper(value)
+		case "title":
+			value = titleCase(value)
+		case "trimspace":
+			value = strings.TrimSpace(value)
+		default:
+			return "", fmt.Errorf("unknown transform %q", name)
+		}
+	}
+	return value, nil
+}
+
+// titleCase converts the first letter of every word of value to title case, leaving the rest as is.
+func titleCase(value string) string {
+	runes := []rune(value)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToTitle(r)
 		}
-		result += string(r)
 	}
-	return strings.ToUpper(result)
+	return string(runes)
 }
 
+// mapSep returns the separator of map keys and values, from the "kvsep" tag or the MapSep option.
+func (f *filler) mapSep(tag reflect.StructTag) string {
+	if tag.Get("kvsep") == "" && f.kvsep != "" {
+		return f.kvsep
+	}
+	return mapSep(tag)
+}
+
+// splitValues splits the value of a slice or array by sep.
+// With the csv:"true" tag the value is read as a CSV record, so elements can be quoted to contain the separator.
+func splitValues(value, sep string, tag reflect.StructTag) ([]string, error) {
+	if sep == "\n" {
+		return splitLines(value), nil
+	}
+	if tag.Get("csv") != "true" {
+		return strings.Split(value, sep), nil
+	}
+	reader := csv.NewReader(strings.NewReader(value))
+	reader.Comma, _ = utf8.DecodeRuneInString(sep)
+	return reader.Read()
+}
+
+// mapSep returns the separator of map keys and values, set with the "kvsep" tag.
+func mapSep(tag reflect.StructTag) string {
+	if sep := tag.Get("kvsep"); sep != "" {
+		return sep
+	}
+	return ":"
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	urlType             = reflect.TypeOf(url.URL{})
+	urlValuesType       = reflect.TypeOf(url.Values{})
+	ipNetType           = reflect.TypeOf(net.IPNet{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
 // setFieldValue sets the value of a field based on the environment variable value.
-func setFieldValue(fieldValue reflect.Value, envValue string) {
+// The tag of the struct field is used to customize the conversion.
+// The field is left untouched if the value can't be converted.
+func (f *filler) setFieldValue(fieldValue reflect.Value, envValue string, tag reflect.StructTag) error {
+	if tag.Get("trim") == "true" {
+		// Trimming here also trims every element of slices and maps, as they are set recursively
+		envValue = strings.TrimSpace(envValue)
+	}
+
+	switch fieldValue.Type() {
+	case durationType:
+		parse := time.ParseDuration
+		if tag.Get("iso8601") == "true" {
+			parse = parseISODuration
+		}
+		duration, err := parse(envValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(int64(duration))
+		return nil
+	case timeType:
+		layout := tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		timeValue, err := time.Parse(layout, envValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(timeValue))
+		return nil
+	case urlType:
+		urlValue, err := url.Parse(envValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(*urlValue))
+		return nil
+	case urlValuesType:
+		values, err := url.ParseQuery(envValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(values))
+		return nil
+	case ipNetType:
+		_, network, err := net.ParseCIDR(envValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(*network))
+		return nil
+	}
+
+	if fieldValue.Kind() != reflect.Ptr && fieldValue.CanAddr() {
+		if unmarshaler, ok := fieldValue.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(envValue))
+		}
+		if value, ok := fieldValue.Addr().Interface().(flag.Value); ok {
+			return value.Set(envValue)
+		}
+	}
+
+	if kind := fieldValue.Kind(); (kind == reflect.Slice || kind == reflect.Map) && isNested(fieldValue.Type().Elem()) && !isSet(fieldValue.Type()) {
+		return setJSONValue(fieldValue, envValue)
+	}
+
 	switch fieldValue.Kind() {
 	case reflect.Ptr:
 		ptrValue := reflect.New(fieldValue.Type().Elem())
-		setFieldValue(ptrValue.Elem(), envValue)
+		if err := f.setFieldValue(ptrValue.Elem(), envValue, tag); err != nil {
+			return err
+		}
 		fieldValue.Set(ptrValue)
 	case reflect.Slice:
-		values := strings.Split(envValue, ",")
+		if fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte and types like json.RawMessage get the raw value
+			fieldValue.Set(reflect.ValueOf([]byte(envValue)).Convert(fieldValue.Type()))
+			return nil
+		}
+		if envValue == "" {
+			fieldValue.Set(reflect.MakeSlice(fieldValue.Type(), 0, 0))
+			return nil
+		}
+		values, err := splitValues(envValue, f.sliceSep(tag), tag)
+		if err != nil {
+			return err
+		}
 		slice := reflect.MakeSlice(fieldValue.Type(), len(values), len(values))
 		for i, value := range values {
-			setFieldValue(slice.Index(i), value)
+			if err := f.setFieldValue(slice.Index(i), value, tag); err != nil {
+				return err
+			}
 		}
 		fieldValue.Set(slice)
+	case reflect.Array:
+		values, err := splitValues(envValue, f.sliceSep(tag), tag)
+		if err != nil {
+			return err
+		}
+		if len(values) != fieldValue.Len() {
+			return fmt.Errorf("expected %d values, got %d", fieldValue.Len(), len(values))
+		}
+		array := reflect.New(fieldValue.Type()).Elem()
+		for i, value := range values {
+			if err := f.setFieldValue(array.Index(i), value, tag); err != nil {
+				return err
+			}
+		}
+		fieldValue.Set(array)
 	case reflect.Map:
 		elemType := fieldValue.Type().Elem()
 		keyType := fieldValue.Type().Key()
 		mapValue := reflect.MakeMap(fieldValue.Type())
-		pairs := strings.Split(envValue, ",")
+		if envValue == "" {
+			if fieldValue.IsNil() {
+				fieldValue.Set(mapValue)
+			}
+			return nil
+		}
+		pairs := strings.Split(envValue, f.sliceSep(tag))
+		if f.sliceSep(tag) == "\n" {
+			pairs = splitLines(envValue)
+		}
 		for _, pair := range pairs {
-			kv := strings.SplitN(pair, ":", 2)
+			if isSet(fieldValue.Type()) {
+				// The elements of a set are only keys
+				key := reflect.New(keyType).Elem()
+				if err := f.setFieldValue(key, pair, tag); err != nil {
+					return fmt.Errorf("invalid element %q: %w", pair, err)
+				}
+				mapValue.SetMapIndex(key, reflect.New(elemType).Elem())
+				continue
+			}
+			kv := strings.SplitN(pair, f.mapSep(tag), 2)
 			if len(kv) != 2 {
+				if f.strict {
+					return fmt.Errorf("missing %q in %q", f.mapSep(tag), pair)
+				}
 				continue
 			}
+			// A pair that can't be converted fails the whole map instead of adding a zero key or value
 			key := reflect.New(keyType).Elem()
-			setFieldValue(key, kv[0])
+			if err := f.setFieldValue(key, kv[0], tag); err != nil {
+				return fmt.Errorf("invalid key in pair %q: %w", pair, err)
+			}
 			value := reflect.New(elemType).Elem()
-			setFieldValue(value, kv[1])
+			if err := f.setFieldValue(value, kv[1], tag); err != nil {
+				return fmt.Errorf("invalid value in pair %q: %w", pair, err)
+			}
 			mapValue.SetMapIndex(key, value)
 		}
-		fieldValue.Set(mapValue)
+		if fieldValue.IsNil() {
+			fieldValue.Set(mapValue)
+			return nil
+		}
+		// Merge into the existing map, only once all the pairs are valid
+		iter := mapValue.MapRange()
+		for iter.Next() {
+			fieldValue.SetMapIndex(iter.Key(), iter.Value())
+		}
 	case reflect.String:
+		if tag.Get("expand") == "true" {
+			envValue = f.expand(envValue)
+		}
+		envValue = strings.TrimPrefix(envValue, tag.Get("trimprefix"))
+		envValue = strings.TrimSuffix(envValue, tag.Get("trimsuffix"))
+		if transforms, ok := tag.Lookup("transform"); ok {
+			value, err := transform(envValue, transforms)
+			if err != nil {
+				return err
+			}
+			envValue = value
+		}
 		fieldValue.SetString(envValue)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if intValue, err := strconv.ParseInt(envValue, 10, 64); err == nil {
-			fieldValue.SetInt(intValue)
+		if tag.Get("size") == "true" {
+			size, err := parseSignedSize(envValue)
+			if err != nil {
+				return err
+			}
+			if fieldValue.OverflowInt(size) {
+				return errors.New("size out of range")
+			}
+			fieldValue.SetInt(size)
+			return nil
+		}
+		if tag.Get("char") == "true" {
+			char, err := parseChar(envValue)
+			if err != nil {
+				return err
+			}
+			if fieldValue.OverflowInt(int64(char)) {
+				return fmt.Errorf("character %q out of range", char)
+			}
+			fieldValue.SetInt(int64(char))
+			return nil
 		}
+		value, base := intBase(envValue)
+		intValue, err := strconv.ParseInt(value, base, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(intValue)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if uintValue, err := strconv.ParseUint(envValue, 10, 64); err == nil {
-			fieldValue.SetUint(uintValue)
+		if tag.Get("size") == "true" {
+			size, err := parseSize(envValue)
+			if err != nil {
+				return err
+			}
+			if fieldValue.OverflowUint(size) {
+				return errors.New("size out of range")
+			}
+			fieldValue.SetUint(size)
+			return nil
+		}
+		if tag.Get("char") == "true" {
+			char, err := parseChar(envValue)
+			if err != nil {
+				return err
+			}
+			if fieldValue.OverflowUint(uint64(char)) {
+				return fmt.Errorf("character %q out of range", char)
+			}
+			fieldValue.SetUint(uint64(char))
+			return nil
 		}
+		value, base := intBase(envValue)
+		uintValue, err := strconv.ParseUint(value, base, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetUint(uintValue)
 	case reflect.Float32, reflect.Float64:
-		if floatValue, err := strconv.ParseFloat(envValue, 64); err == nil {
+		if tag.Get("percent") == "true" {
+			floatValue, err := parsePercent(envValue, fieldValue.Type().Bits())
+			if err != nil {
+				return err
+			}
 			fieldValue.SetFloat(floatValue)
+			return nil
+		}
+		floatValue, err := strconv.ParseFloat(envValue, fieldValue.Type().Bits())
+		if err != nil {
+			return err
 		}
+		fieldValue.SetFloat(floatValue)
+	case reflect.Complex64, reflect.Complex128:
+		complexValue, err := strconv.ParseComplex(envValue, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetComplex(complexValue)
 	case reflect.Bool:
-		if boolValue, err := strconv.ParseBool(envValue); err == nil {
-			fieldValue.SetBool(boolValue)
+		if envValue == "" {
+			return setEmptyBool(fieldValue, tag)
 		}
+		switch truthy := tag.Get("truthy"); truthy {
+		case "":
+		case "int":
+			// Any integer is accepted, values that aren't integers are still parsed as bools below
+			value, base := intBase(envValue)
+			if intValue, err := strconv.ParseInt(value, base, 64); err == nil {
+				fieldValue.SetBool(intValue != 0)
+				return nil
+			}
+		default:
+			return fmt.Errorf("invalid truthy tag %q", truthy)
+		}
+		boolValue, err := parseBool(envValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetBool(boolValue)
+	default:
+		if f.strict {
+			return errors.New("unsupported type")
+		}
+	}
+	return nil
+}
+
+// intBase returns the value and base to parse an integer with.
+// Values with a 0x, 0o or 0b prefix use base 0 so strconv handles the prefix and underscores.
+// Other values are decimal, even with leading zeros, and have their underscores removed.
+func intBase(value string) (string, int) {
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 2 && digits[0] == '0' && strings.ContainsRune("xXoObB", rune(digits[1])) {
+		return value, 0
+	}
+	return strings.ReplaceAll(value, "_", ""), 10
+}
+
+// parseChar parses a value made of a single character and returns its code point.
+func parseChar(value string) (rune, error) {
+	char, size := utf8.DecodeRuneInString(value)
+	if value == "" || size != len(value) || char == utf8.RuneError {
+		return 0, fmt.Errorf("expected a single character, got %q", value)
+	}
+	return char, nil
+}
+
+// parsePercent parses a percentage like 5% as the fraction 0.05. A number without % is already a fraction.
+func parsePercent(value string, bits int) (float64, error) {
+	number, isPercent := strings.CutSuffix(value, "%")
+	fraction, err := strconv.ParseFloat(strings.TrimSpace(number), bits)
+	if err != nil {
+		return 0, errors.New("expected a number or a percentage like 5%")
+	}
+	if isPercent {
+		fraction /= 100
+	}
+	return fraction, nil
+}
+
+// parseBool works like strconv.ParseBool but also accepts yes/no, on/off and enabled/disabled in any case.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "on", "enabled":
+		return true, nil
+	case "no", "off", "disabled":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// setEmptyBool sets a bool whose variable is set to an empty string, following the "emptybool" tag.
+// By default the variable being set means true, like a flag without value.
+// With emptybool:"false" it is false and with emptybool:"error" it is an error.
+func setEmptyBool(fieldValue reflect.Value, tag reflect.StructTag) error {
+	switch mode := tag.Get("emptybool"); mode {
+	case "", "true":
+		fieldValue.SetBool(true)
+	case "false":
+		fieldValue.SetBool(false)
+	case "error":
+		return errors.New("empty value")
+	default:
+		return fmt.Errorf("invalid emptybool tag %q", mode)
+	}
+	return nil
+}
+
+// setJSONValue sets a field by decoding the JSON value.
+func setJSONValue(fieldValue reflect.Value, envValue string) error {
+	value := reflect.New(fieldValue.Type())
+	if err := json.Unmarshal([]byte(envValue), value.Interface()); err != nil {
+		return err
 	}
+	fieldValue.Set(value.Elem())
+	return nil
 }
@@ -1,12 +1,164 @@
 package lazyenv
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
+// stubTerminal makes the terminal detection of Env return terminal until the test ends.
+func stubTerminal(t *testing.T, terminal bool) {
+	original := isTerminal
+	isTerminal = func() bool { return terminal }
+	t.Cleanup(func() { isTerminal = original })
+}
+
+func TestEnv(t *testing.T) {
+	stubTerminal(t, false)
+
+	tests := []struct {
+		environment string
+		expected    string
+	}{
+		{"development", "development"},
+		{"dev", "development"},
+		{"staging", "staging"},
+		{"stage", "staging"},
+		{"test", "test"},
+		{"testing", "test"},
+		{"production", "production"},
+		{"unknown", "production"},
+		{"", "production"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.environment, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", test.environment)
+			if env := Env(); env != test.expected {
+				t.Errorf("Env() = %s; expected %s", env, test.expected)
+			}
+			if IsProduction() != (test.expected == "production") ||
+				IsDevelopment() != (test.expected == "development") ||
+				IsStaging() != (test.expected == "staging") ||
+				IsTest() != (test.expected == "test") {
+				t.Errorf("Is* helpers don't match %s", test.expected)
+			}
+		})
+	}
+}
+
+func TestEnvTerminal(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "")
+
+	stubTerminal(t, true)
+	if env := Env(); env != "development" {
+		t.Errorf("Env() = %s; expected development on a terminal", env)
+	}
+
+	t.Setenv("ENVIRONMENT", "staging")
+	if env := Env(); env != "staging" {
+		t.Errorf("Env() = %s; expected ENVIRONMENT to take precedence over the terminal", env)
+	}
+}
+
+func TestEnvVarNames(t *testing.T) {
+	stubTerminal(t, false)
+	t.Setenv("ENVIRONMENT", "")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("GO_ENV", "development")
+	t.Setenv("RAILS_ENV", "test")
+
+	if env := Env(); env != "staging" {
+		t.Errorf("Env() = %s; expected APP_ENV to be used", env)
+	}
+
+	t.Setenv("ENVIRONMENT", "development")
+	if env := Env(); env != "development" {
+		t.Errorf("Env() = %s; expected ENVIRONMENT to take precedence", env)
+	}
+
+	SetEnvVarNames("RAILS_ENV", "ENVIRONMENT")
+	defer SetEnvVarNames()
+	if env := Env(); env != "test" {
+		t.Errorf("Env() = %s; expected RAILS_ENV to be used", env)
+	}
+
+	SetEnvVarNames()
+	if env := Env(); env != "development" {
+		t.Errorf("Env() = %s; expected the default names to be restored", env)
+	}
+}
+
+func TestSetEnv(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	defer ResetEnv()
+
+	SetEnv(development)
+	if !IsDevelopment() || IsProduction() {
+		t.Errorf("Env() = %s; expected the override", Env())
+	}
+
+	SetEnv(staging)
+	if !IsStaging() {
+		t.Errorf("Env() = %s; expected the override", Env())
+	}
+
+	ResetEnv()
+	stubTerminal(t, false)
+	if !IsProduction() {
+		t.Errorf("Env() = %s; expected the automatic detection", Env())
+	}
+}
+
+func TestEnvReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		override string
+		terminal bool
+		expect   string
+		reason   string
+	}{
+		{"override", map[string]string{"ENVIRONMENT": "production"}, staging, false, staging, "SetEnv"},
+		{"environment", map[string]string{"ENVIRONMENT": "dev"}, "", false, development, "ENVIRONMENT prefix"},
+		{"app env", map[string]string{"APP_ENV": "testing"}, "", true, test, "APP_ENV prefix"},
+		{"terminal", map[string]string{"ENVIRONMENT": "production"}, "", true, development, "stdout or stderr is a terminal"},
+		{"default", nil, "", false, production, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range defaultEnvVarNames {
+				t.Setenv(name, tt.env[name])
+			}
+			stubTerminal(t, tt.terminal)
+			SetEnv(tt.override)
+			defer ResetEnv()
+
+			env, reason := EnvReason()
+			if env != tt.expect || reason != tt.reason {
+				t.Errorf("EnvReason() = %s, %q; expected %s, %q", env, reason, tt.expect, tt.reason)
+			}
+			if Env() != env {
+				t.Errorf("Env() = %s; expected %s like EnvReason", Env(), env)
+			}
+		})
+	}
+}
+
 func TestFillWithNestedStructs(t *testing.T) {
 	envVars := map[string]string{
 		"DB_NAME": "test_db",
@@ -64,6 +216,19 @@ func TestToEnvName(t *testing.T) {
 		{"MyVar", "MY_VAR"},
 		{"simple", "SIMPLE"},
 		{"AnotherExample", "ANOTHER_EXAMPLE"},
+		{"ID", "ID"},
+		{"OAuth2Token", "OAUTH2_TOKEN"},
+		{"S3Bucket", "S3_BUCKET"},
+		{"UserID2", "USER_ID2"},
+		{"ABTest", "AB_TEST"},
+		{"MD5Sum", "MD5_SUM"},
+		{"Version2", "VERSION2"},
+		{"IPv6Addr", "IPV6_ADDR"},
+		{"My_Var", "MY_VAR"},
+		{"GetAValue", "GET_A_VALUE"},
+		{"XAxis", "XAXIS"},
+		{"ATest", "ATEST"},
+		{"My_OAuth", "MY_OAUTH"},
 	}
 
 	for _, test := range tests {
@@ -170,6 +335,2668 @@ func TestFillFromEnvWithoutTags(t *testing.T) {
 	}
 }
 
+func TestFillE(t *testing.T) {
+	t.Setenv("USER_ID", "abc")
+	t.Setenv("SIMPLE", "true")
+	t.Setenv("DB_NAME", "test_db")
+	t.Setenv("DB_PORT", "not_a_port")
+
+	type Config struct {
+		UserID int
+		Simple bool
+		DB     struct {
+			Name string
+			Port int
+		}
+	}
+
+	var config Config
+	err := FillE(&config)
+	if err == nil {
+		t.Fatal("FillE() returned no error")
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("FillE() error = %T; expected Errors", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("FillE() returned %d errors; expected 2: %v", len(errs), err)
+	}
+
+	expect := []FieldError{
+		{Path: "UserID", Key: "USER_ID", Value: "abc", Kind: reflect.Int},
+		{Path: "DB.Port", Key: "DB_PORT", Value: "not_a_port", Kind: reflect.Int},
+	}
+	for i, e := range expect {
+		got := errs[i]
+		if got.Path != e.Path || got.Key != e.Key || got.Value != e.Value || got.Kind != e.Kind || got.Err == nil {
+			t.Errorf("error %d = %+v; expected %+v", i, got, e)
+		}
+	}
+
+	if !config.Simple || config.DB.Name != "test_db" {
+		t.Errorf("FillE() = %+v; expected valid fields to be filled", config)
+	}
+}
+
+func TestFillENoError(t *testing.T) {
+	t.Setenv("USER_ID", "42")
+
+	var config struct {
+		UserID int
+	}
+	if err := FillE(&config); err != nil {
+		t.Errorf("FillE() error = %v; expected nil", err)
+	}
+	if config.UserID != 42 {
+		t.Errorf("FillE() UserID = %d; expected 42", config.UserID)
+	}
+}
+
+func TestFillDuration(t *testing.T) {
+	t.Setenv("TIMEOUT", "1m30s")
+	t.Setenv("RETRIES", "1s,2s,3s")
+	t.Setenv("IDLE", "500ms")
+
+	type Config struct {
+		Timeout time.Duration
+		Retries []time.Duration
+		Idle    *time.Duration
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	idle := 500 * time.Millisecond
+	expect := Config{
+		Timeout: 90 * time.Second,
+		Retries: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		Idle:    &idle,
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillInvalidDuration(t *testing.T) {
+	t.Setenv("TIMEOUT", "30 seconds")
+
+	var config struct {
+		Timeout time.Duration
+	}
+	err := FillE(&config)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "TIMEOUT" {
+		t.Errorf("FillE() error = %v; expected a FieldError for TIMEOUT", err)
+	}
+}
+
+func TestFillTime(t *testing.T) {
+	t.Setenv("DEPLOYED_AT", "2024-01-02T15:04:05Z")
+	t.Setenv("RELEASED_ON", "2024-03-04")
+	t.Setenv("CHECKED_AT", "2024-01-02T15:04:05Z")
+	t.Setenv("HOLIDAYS", "2024-12-25,2025-01-01")
+
+	type Config struct {
+		DeployedAt time.Time
+		ReleasedOn time.Time `layout:"2006-01-02"`
+		CheckedAt  *time.Time
+		Holidays   []time.Time `layout:"2006-01-02"`
+		MissingAt  *time.Time
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	deployed := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	expect := Config{
+		DeployedAt: deployed,
+		ReleasedOn: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		CheckedAt:  &deployed,
+		Holidays: []time.Time{
+			time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillInvalidTime(t *testing.T) {
+	t.Setenv("DEPLOYED_AT", "yesterday")
+
+	var config struct {
+		DeployedAt time.Time
+	}
+	err := FillE(&config)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "DEPLOYED_AT" {
+		t.Errorf("FillE() error = %v; expected a FieldError for DEPLOYED_AT", err)
+	}
+	if !config.DeployedAt.IsZero() {
+		t.Errorf("FillE() DeployedAt = %v; expected zero time", config.DeployedAt)
+	}
+}
+
+func TestFillDefaults(t *testing.T) {
+	t.Setenv("HOST", "example.com")
+	t.Setenv("NAME", "")
+
+	type Config struct {
+		Host    string            `default:"localhost"`
+		Port    int               `default:"8080"`
+		Name    string            `default:"app"`
+		Debug   bool              `default:"true"`
+		Tags    []string          `default:"a,b"`
+		Labels  map[string]string `default:"env:dev"`
+		Timeout time.Duration     `default:"5s"`
+		DB      struct {
+			Port int `default:"5432"`
+		}
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	var expect Config
+	expect.Host = "example.com"
+	expect.Port = 8080
+	expect.Debug = true
+	expect.Tags = []string{"a", "b"}
+	expect.Labels = map[string]string{"env": "dev"}
+	expect.Timeout = 5 * time.Second
+	expect.DB.Port = 5432
+
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillEmptyValues(t *testing.T) {
+	t.Setenv("NAME", "")
+	t.Setenv("HOSTS", "")
+	t.Setenv("FEATURE_FLAG", "")
+
+	type Config struct {
+		Name        string
+		Missing     string
+		Hosts       []string
+		FeatureFlag bool `emptybool:"error"`
+	}
+
+	config := Config{Name: "initial", Missing: "initial", Hosts: []string{"a"}}
+	err := FillE(&config)
+
+	expect := Config{Name: "", Missing: "initial", Hosts: []string{}}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "FEATURE_FLAG" {
+		t.Errorf("FillE() error = %v; expected a FieldError for FEATURE_FLAG", err)
+	}
+}
+
+func TestFillRequired(t *testing.T) {
+	t.Setenv("HOST", "localhost")
+
+	type Config struct {
+		Host string `required:"true"`
+		Port int    `required:"true" default:"8080"`
+		Name string `required:"true"`
+		DB   struct {
+			URL string `required:"true"`
+		}
+	}
+
+	var config Config
+	err := FillE(&config)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("FillE() error = %v; expected Errors", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Path+" "+e.Key)
+	}
+	expect := []string{"Name NAME", "DB.URL DB_URL"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("FillE() errors = %v; expected %v", got, expect)
+	}
+
+	if config.Host != "localhost" || config.Port != 8080 {
+		t.Errorf("FillE() = %+v; expected Host and Port to be filled", config)
+	}
+}
+
+func TestFillFrom(t *testing.T) {
+	source := map[string]string{
+		"DB_NAME": "remote_db",
+		"DB_PORT": "5432",
+		"DEBUG":   "true",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := source[key]
+		return value, ok
+	}
+
+	type Config struct {
+		DB struct {
+			Name string
+			Port int
+		}
+		Debug bool
+		Host  string `default:"localhost"`
+	}
+
+	var config Config
+	FillFrom(&config, lookup)
+
+	var expect Config
+	expect.DB.Name = "remote_db"
+	expect.DB.Port = 5432
+	expect.Debug = true
+	expect.Host = "localhost"
+
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillFrom() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillSeparators(t *testing.T) {
+	t.Setenv("PATHS", "/a/b;/c/d")
+	t.Setenv("FRAGMENTS", "a,b|c,d")
+	t.Setenv("LABELS", "env=dev;team=core")
+
+	type Config struct {
+		Paths     []string          `sep:";"`
+		Fragments []string          `sep:"|"`
+		Labels    map[string]string `sep:";" kvsep:"="`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Paths:     []string{"/a/b", "/c/d"},
+		Fragments: []string{"a,b", "c,d"},
+		Labels:    map[string]string{"env": "dev", "team": "core"},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillBytes(t *testing.T) {
+	t.Setenv("TOKEN", "abc")
+	t.Setenv("PAYLOAD", `{"a":1,"b":[1,2]}`)
+
+	type Config struct {
+		Token   []byte
+		Payload json.RawMessage
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Token:   []byte("abc"),
+		Payload: json.RawMessage(`{"a":1,"b":[1,2]}`),
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+type logLevel int
+
+func (l *logLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	case "error":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown log level %q", text)
+	}
+	return nil
+}
+
+func TestFillTextUnmarshaler(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("BIND", "10.0.0.1")
+	t.Setenv("PEERS", "10.0.0.2,::1")
+	t.Setenv("HOMEPAGE", "https://example.com/path?q=1")
+	t.Setenv("API", "http://localhost:8080")
+
+	type Config struct {
+		LogLevel logLevel
+		Bind     net.IP
+		Peers    []net.IP
+		Homepage url.URL
+		API      *url.URL
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	if config.LogLevel != 2 {
+		t.Errorf("LogLevel = %v; expected 2", config.LogLevel)
+	}
+	if !config.Bind.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Bind = %v; expected 10.0.0.1", config.Bind)
+	}
+	if len(config.Peers) != 2 || !config.Peers[1].Equal(net.IPv6loopback) {
+		t.Errorf("Peers = %v; expected [10.0.0.2 ::1]", config.Peers)
+	}
+	if config.Homepage.Host != "example.com" || config.Homepage.Path != "/path" {
+		t.Errorf("Homepage = %v; expected https://example.com/path?q=1", config.Homepage.String())
+	}
+	if config.API == nil || config.API.Host != "localhost:8080" {
+		t.Errorf("API = %v; expected http://localhost:8080", config.API)
+	}
+}
+
+func TestFillTextUnmarshalerError(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("BIND", "not_an_ip")
+
+	var config struct {
+		LogLevel logLevel
+		Bind     net.IP
+	}
+	err := FillE(&config)
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("FillE() error = %v; expected errors for LOG_LEVEL and BIND", err)
+	}
+}
+
+// endpoint can be set from a single "host:port" value or from its fields.
+type endpoint struct {
+	Host string
+	Port int
+}
+
+func (e *endpoint) UnmarshalText(text []byte) error {
+	host, port, err := net.SplitHostPort(string(text))
+	if err != nil {
+		return err
+	}
+	e.Host = host
+	e.Port, err = strconv.Atoi(port)
+	return err
+}
+
+func TestFillTextUnmarshalerStruct(t *testing.T) {
+	t.Setenv("PRIMARY", "db1.example.com:5432")
+	t.Setenv("PRIMARY_HOST", "ignored.example.com")
+	t.Setenv("REPLICA_HOST", "db2.example.com")
+	t.Setenv("REPLICA_PORT", "5433")
+
+	type Config struct {
+		Primary endpoint
+		Replica endpoint
+		Backup  endpoint `default:"db3.example.com:5434"`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Primary: endpoint{Host: "db1.example.com", Port: 5432},
+		Replica: endpoint{Host: "db2.example.com", Port: 5433},
+		Backup:  endpoint{Host: "db3.example.com", Port: 5434},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillNestedTime(t *testing.T) {
+	t.Setenv("SCHEDULE_START", "2024-01-02T03:04:05Z")
+
+	type Config struct {
+		Schedule struct {
+			Start time.Time
+			End   time.Time
+		}
+	}
+
+	var config Config
+	var sources []string
+	FillWithHook(&config, func(fieldPath, envKey, rawValue string, source Source) {
+		sources = append(sources, fieldPath+"="+source.String())
+	})
+
+	if expect := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !config.Schedule.Start.Equal(expect) {
+		t.Errorf("Schedule.Start = %v; expected %v", config.Schedule.Start, expect)
+	}
+	if !config.Schedule.End.IsZero() {
+		t.Errorf("Schedule.End = %v; expected zero time", config.Schedule.End)
+	}
+	expect := []string{"Schedule.Start=env", "Schedule.End=unset"}
+	if !reflect.DeepEqual(sources, expect) {
+		t.Errorf("sources = %v; expected %v", sources, expect)
+	}
+}
+
+func TestFillPrefixed(t *testing.T) {
+	t.Setenv("MYAPP_DB_HOST", "db.example.com")
+	t.Setenv("MYAPP_CACHE_TTL", "5s")
+	t.Setenv("DB_HOST", "wrong")
+
+	type Config struct {
+		DBHost string
+		Cache  struct {
+			TTL time.Duration
+		}
+	}
+
+	for _, prefix := range []string{"MYAPP", "MYAPP_"} {
+		var config Config
+		FillPrefixed(&config, prefix)
+		if config.DBHost != "db.example.com" || config.Cache.TTL != 5*time.Second {
+			t.Errorf("FillPrefixed(%q) = %+v; expected MYAPP_ variables", prefix, config)
+		}
+	}
+
+	var config Config
+	FillPrefixed(&config, "")
+	if config.DBHost != "wrong" {
+		t.Errorf("FillPrefixed(\"\") DBHost = %q; expected %q", config.DBHost, "wrong")
+	}
+}
+
+func TestFillSkip(t *testing.T) {
+	t.Setenv("INTERNAL", "from_env")
+	t.Setenv("NAME", "app")
+	t.Setenv("CACHE_SIZE", "10")
+
+	type Config struct {
+		Name     string
+		Internal string `env:"-"`
+		Cache    *struct {
+			Size int
+		} `env:"-"`
+	}
+
+	var config Config
+	Fill(&config)
+
+	if config.Name != "app" || config.Internal != "" || config.Cache != nil {
+		t.Errorf("Fill() = %+v; expected Internal and Cache to be skipped", config)
+	}
+}
+
+func TestFillUnexported(t *testing.T) {
+	t.Setenv("NAME", "app")
+	t.Setenv("CACHE", "a:b")
+	t.Setenv("COUNT", "3")
+	t.Setenv("DB_HOST", "localhost")
+
+	type Config struct {
+		Name  string
+		cache map[string]string
+		count int
+		db    *struct{ Host string }
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	if config.Name != "app" || config.cache != nil || config.count != 0 || config.db != nil {
+		t.Errorf("FillE() = %+v; expected unexported fields to be skipped", config)
+	}
+}
+
+func TestFillComplex(t *testing.T) {
+	t.Setenv("GAIN", "3+4i")
+	t.Setenv("PHASE", "1.5-2i")
+	t.Setenv("GAINS", "1+1i,2,3i")
+	t.Setenv("OFFSET", "-1i")
+
+	type Config struct {
+		Gain   complex128
+		Phase  complex64
+		Gains  []complex128
+		Offset *complex128
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	offset := complex(0, -1)
+	expect := Config{
+		Gain:   complex(3, 4),
+		Phase:  complex(1.5, -2),
+		Gains:  []complex128{complex(1, 1), complex(2, 0), complex(0, 3)},
+		Offset: &offset,
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+
+	t.Setenv("GAIN", "loud")
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "GAIN" {
+		t.Errorf("FillE() error = %v; expected a FieldError for GAIN", err)
+	}
+}
+
+func TestFillCaseInsensitive(t *testing.T) {
+	t.Setenv("db_name", "test_db")
+	t.Setenv("User_Id", "42")
+	t.Setenv("HTTP_SERVER", "localhost")
+
+	type Config struct {
+		DB struct {
+			Name string
+		}
+		UserID     int
+		HTTPServer string
+		Server     string `env:"http_server"`
+	}
+
+	var config Config
+	FillCaseInsensitive(&config)
+
+	if config.DB.Name != "test_db" || config.UserID != 42 || config.HTTPServer != "localhost" || config.Server != "localhost" {
+		t.Errorf("FillCaseInsensitive() = %+v; expected all fields to be filled", config)
+	}
+}
+
+type validatedDB struct {
+	Port int
+}
+
+func (db validatedDB) Validate() error {
+	if db.Port < 1 || db.Port > 65535 {
+		return fmt.Errorf("port must be 1-65535, got %d", db.Port)
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	DB validatedDB
+}
+
+func (c *validatedConfig) Validate() error {
+	return errors.New("root is invalid")
+}
+
+func TestFillValidate(t *testing.T) {
+	t.Setenv("DB_PORT", "70000")
+
+	var config validatedConfig
+	err := FillE(&config)
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("FillE() error = %v; expected 2 validation errors", err)
+	}
+	if errs[0].Path != "DB" || errs[1].Path != "" {
+		t.Errorf("FillE() errors = %v; expected DB to be validated before the root", err)
+	}
+	if config.DB.Port != 70000 {
+		t.Errorf("FillE() DB.Port = %d; expected the value to be filled before validating", config.DB.Port)
+	}
+
+	t.Setenv("DB_PORT", "5432")
+	var db struct{ DB validatedDB }
+	if err := FillE(&db); err != nil {
+		t.Errorf("FillE() error = %v; expected nil", err)
+	}
+}
+
+func TestFillJSONStructs(t *testing.T) {
+	type Server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+
+	t.Setenv("SERVERS", `[{"host":"a"},{"host":"b","port":8080}]`)
+	t.Setenv("BY_NAME", `{"main":{"host":"a"}}`)
+	t.Setenv("POINTERS", `[{"host":"c"}]`)
+	t.Setenv("NAMES", "x,y")
+
+	type Config struct {
+		Servers  []Server
+		ByName   map[string]Server
+		Pointers []*Server
+		Names    []string
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Servers:  []Server{{Host: "a"}, {Host: "b", Port: 8080}},
+		ByName:   map[string]Server{"main": {Host: "a"}},
+		Pointers: []*Server{{Host: "c"}},
+		Names:    []string{"x", "y"},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+
+	t.Setenv("SERVERS", "a,b")
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "SERVERS" {
+		t.Errorf("FillE() error = %v; expected a FieldError for SERVERS", err)
+	}
+}
+
+func TestFillMissing(t *testing.T) {
+	t.Setenv("HOST", "env.example.com")
+	t.Setenv("PORT", "9090")
+	t.Setenv("DB_NAME", "env_db")
+	t.Setenv("DB_USER", "env_user")
+
+	type Config struct {
+		Host string
+		Port int
+		DB   struct {
+			Name string
+			User string
+		}
+	}
+
+	var config Config
+	config.Host = "localhost"
+	config.DB.Name = "app_db"
+	FillMissing(&config)
+
+	var expect Config
+	expect.Host = "localhost"
+	expect.Port = 9090
+	expect.DB.Name = "app_db"
+	expect.DB.User = "env_user"
+
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillMissing() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1", true},
+		{"t", true},
+		{"TRUE", true},
+		{"yes", true},
+		{"On", true},
+		{"ENABLED", true},
+		{"0", false},
+		{"false", false},
+		{"NO", false},
+		{"off", false},
+		{"Disabled", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := parseBool(test.input)
+			if err != nil || result != test.expected {
+				t.Errorf("parseBool(%s) = %v, %v; expected %v", test.input, result, err, test.expected)
+			}
+		})
+	}
+
+	if _, err := parseBool("maybe"); err == nil {
+		t.Errorf("parseBool(maybe) returned no error")
+	}
+}
+
+func TestMustFill(t *testing.T) {
+	t.Setenv("PORT", "http")
+
+	var config struct {
+		Port int
+		Host string `required:"true"`
+	}
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok {
+			t.Fatalf("MustFill() didn't panic with an error")
+		}
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Errorf("MustFill() panic = %v; expected errors for PORT and HOST", err)
+		}
+	}()
+	MustFill(&config)
+}
+
+func TestFillRemainder(t *testing.T) {
+	t.Setenv("PLUGIN_FOO", "1")
+	t.Setenv("PLUGIN_BAR", "2")
+	t.Setenv("PLUGINS", "3")
+	t.Setenv("OTHER_FOO", "4")
+
+	expect := map[string]string{"FOO": "1", "BAR": "2"}
+	for _, prefix := range []string{"PLUGIN", "PLUGIN_"} {
+		if got := FillRemainder(prefix); !reflect.DeepEqual(got, expect) {
+			t.Errorf("FillRemainder(%q) = %v; expected %v", prefix, got, expect)
+		}
+	}
+	if got := FillRemainder(""); len(got) != 0 {
+		t.Errorf("FillRemainder(\"\") = %v; expected an empty map", got)
+	}
+}
+
+func TestFillArray(t *testing.T) {
+	t.Setenv("COLOR", "0.1,0.2,0.3")
+	t.Setenv("PAIR", "a;b")
+
+	type Config struct {
+		Color [3]float64
+		Pair  [2]string `sep:";"`
+		Size  [2]int
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Color: [3]float64{0.1, 0.2, 0.3},
+		Pair:  [2]string{"a", "b"},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+
+	t.Setenv("SIZE", "1,2,3")
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "SIZE" {
+		t.Errorf("FillE() error = %v; expected a FieldError for SIZE", err)
+	}
+	if config.Size != [2]int{} {
+		t.Errorf("FillE() Size = %v; expected it to be left untouched", config.Size)
+	}
+}
+
+func TestFillTrim(t *testing.T) {
+	t.Setenv("HOSTS", "a, b ,  c")
+	t.Setenv("RAW_HOSTS", "a, b")
+	t.Setenv("LABELS", "env : dev, team:core ")
+	t.Setenv("NAME", "  app ")
+	t.Setenv("PORTS", "80, 443")
+
+	type Config struct {
+		Hosts    []string `trim:"true"`
+		RawHosts []string
+		Labels   map[string]string `trim:"true"`
+		Name     string            `trim:"true"`
+		Ports    []int             `trim:"true"`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Hosts:    []string{"a", "b", "c"},
+		RawHosts: []string{"a", " b"},
+		Labels:   map[string]string{"env": "dev", "team": "core"},
+		Name:     "app",
+		Ports:    []int{80, 443},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillWithHook(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+
+	type Config struct {
+		DB struct {
+			Host string
+			Port int `default:"5432"`
+		}
+		Name string
+	}
+
+	var got []string
+	var config Config
+	FillWithHook(&config, func(fieldPath, envKey, rawValue string, source Source) {
+		got = append(got, fmt.Sprintf("%s %s %q %s", fieldPath, envKey, rawValue, source))
+	})
+
+	expect := []string{
+		`DB.Host DB_HOST "localhost" env`,
+		`DB.Port DB_PORT "5432" default`,
+		`Name NAME "" unset`,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("FillWithHook() calls = %q; expected %q", got, expect)
+	}
+	if config.DB.Host != "localhost" || config.DB.Port != 5432 {
+		t.Errorf("FillWithHook() = %+v; expected the fields to be filled", config)
+	}
+}
+
+func TestFillWithHookSecrets(t *testing.T) {
+	t.Setenv("PASSWORD", "hunter2")
+	t.Setenv("DB_PASS", "secret")
+
+	type Config struct {
+		Password string `secret:"true"`
+		Token    string `secret:"true"`
+		DB       struct {
+			Pass string
+		} `secret:"true"`
+	}
+
+	got := map[string]string{}
+	var config Config
+	FillWithHook(&config, func(fieldPath, envKey, rawValue string, source Source) {
+		got[envKey] = rawValue
+	})
+
+	expect := map[string]string{"PASSWORD": "***", "TOKEN": "", "DB_PASS": "***"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("FillWithHook() values = %v; expected %v", got, expect)
+	}
+	if config.Password != "hunter2" || config.DB.Pass != "secret" {
+		t.Errorf("FillWithHook() = %+v; expected the real values to be filled", config)
+	}
+}
+
+func TestFillIntegers(t *testing.T) {
+	t.Setenv("BIG", "1_000_000")
+	t.Setenv("MASK", "0xFF")
+	t.Setenv("PERMISSIONS", "0o755")
+	t.Setenv("FLAGS", "0b1010")
+	t.Setenv("PADDED", "0042")
+	t.Setenv("NEGATIVE", "-0x10")
+
+	type Config struct {
+		Big         int
+		Mask        uint8
+		Permissions uint32
+		Flags       int
+		Padded      int
+		Negative    int64
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Big:         1000000,
+		Mask:        255,
+		Permissions: 493,
+		Flags:       10,
+		Padded:      42,
+		Negative:    -16,
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+
+	t.Setenv("MASK", "0x1FF")
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "MASK" {
+		t.Errorf("FillE() error = %v; expected a FieldError for MASK", err)
+	}
+}
+
+func TestFillStrict(t *testing.T) {
+	t.Setenv("LABELS", "env:dev,team")
+	t.Setenv("HANDLER", "something")
+	t.Setenv("PORT", "http")
+
+	type Config struct {
+		Labels  map[string]string
+		Handler func()
+		Port    int
+		Host    string
+	}
+
+	var config Config
+	if err := FillE(&config); err == nil || len(err.(Errors)) != 1 {
+		t.Errorf("FillE() error = %v; expected only the PORT error", err)
+	}
+
+	config = Config{}
+	err := FillStrict(&config)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("FillStrict() error = %v; expected Errors", err)
+	}
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, e.Key)
+	}
+	if expect := []string{"LABELS", "HANDLER", "PORT"}; !reflect.DeepEqual(keys, expect) {
+		t.Errorf("FillStrict() errors = %v; expected %v", err, expect)
+	}
+}
+
+func TestFillNetwork(t *testing.T) {
+	t.Setenv("BIND_ADDR", "10.0.0.1")
+	t.Setenv("ALLOWED_CIDR", "10.1.2.3/8")
+	t.Setenv("PRIVATE", "10.0.0.0/8,192.168.0.0/16")
+	t.Setenv("ADDR", "::1")
+	t.Setenv("ADDRS", "10.0.0.1,10.0.0.2")
+	t.Setenv("PREFIX", "fd00::/8")
+
+	type Config struct {
+		BindAddr    net.IP
+		AllowedCIDR *net.IPNet
+		Private     []net.IPNet
+		Addr        netip.Addr
+		Addrs       []netip.Addr
+		Prefix      netip.Prefix
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	if !config.BindAddr.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("BindAddr = %v; expected 10.0.0.1", config.BindAddr)
+	}
+	if config.AllowedCIDR == nil || config.AllowedCIDR.String() != "10.0.0.0/8" {
+		t.Errorf("AllowedCIDR = %v; expected 10.0.0.0/8", config.AllowedCIDR)
+	}
+	if len(config.Private) != 2 || config.Private[1].String() != "192.168.0.0/16" {
+		t.Errorf("Private = %v; expected [10.0.0.0/8 192.168.0.0/16]", config.Private)
+	}
+	if config.Addr != netip.IPv6Loopback() {
+		t.Errorf("Addr = %v; expected ::1", config.Addr)
+	}
+	if expect := []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2")}; !reflect.DeepEqual(config.Addrs, expect) {
+		t.Errorf("Addrs = %v; expected %v", config.Addrs, expect)
+	}
+	if config.Prefix != netip.MustParsePrefix("fd00::/8") {
+		t.Errorf("Prefix = %v; expected fd00::/8", config.Prefix)
+	}
+
+	t.Setenv("ALLOWED_CIDR", "10.0.0.1")
+	t.Setenv("ADDRS", "10.0.0.1,nope")
+	err := FillE(&config)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Key != "ALLOWED_CIDR" || errs[1].Key != "ADDRS" {
+		t.Errorf("FillE() error = %v; expected errors for ALLOWED_CIDR and ADDRS", err)
+	}
+}
+
+func TestFillAlternateNames(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://old")
+	t.Setenv("NEW_HOST", "new")
+	t.Setenv("OLD_HOST", "old")
+
+	type Config struct {
+		DBURL string `env:"DB_URL,DATABASE_URL"`
+		Host  string `env:"NEW_HOST,OLD_HOST"`
+		Port  int    `env:"NEW_PORT,OLD_PORT" required:"true"`
+	}
+
+	var config Config
+	keys := map[string]string{}
+	FillWithHook(&config, func(fieldPath, envKey, rawValue string, source Source) {
+		keys[fieldPath] = envKey
+	})
+
+	if config.DBURL != "postgres://old" || config.Host != "new" {
+		t.Errorf("FillWithHook() = %+v; expected the first set name to be used", config)
+	}
+	expect := map[string]string{"DBURL": "DATABASE_URL", "Host": "NEW_HOST", "Port": "NEW_PORT"}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Errorf("FillWithHook() keys = %v; expected %v", keys, expect)
+	}
+
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "NEW_PORT" {
+		t.Errorf("FillE() error = %v; expected the canonical name NEW_PORT", err)
+	}
+	if got := Dump(config); got["DB_URL"] != "postgres://old" {
+		t.Errorf("Dump() = %v; expected the canonical name DB_URL", got)
+	}
+}
+
+type Logging struct {
+	Level string
+}
+
+type embeddedBase struct {
+	Name string
+}
+
+func TestFillEmbedded(t *testing.T) {
+	t.Setenv("LEVEL", "debug")
+	t.Setenv("NAME", "app")
+	t.Setenv("AUDIT_LEVEL", "info")
+	t.Setenv("LOGGING_LEVEL", "wrong")
+
+	type Audit struct {
+		Logging `env:"AUDIT"`
+	}
+	type Config struct {
+		Logging
+		embeddedBase
+		Audit
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	if config.Logging.Level != "debug" || config.Name != "app" || config.Audit.Level != "info" {
+		t.Errorf("FillE() = %+v; expected embedded fields to be read without prefix", config)
+	}
+
+	expect := map[string]string{"LEVEL": "debug", "NAME": "app", "AUDIT_LEVEL": "info"}
+	if got := Dump(config); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Dump() = %v; expected %v", got, expect)
+	}
+}
+
+func TestFillCSV(t *testing.T) {
+	t.Setenv("COMMANDS", `"echo a,b","ls -l",pwd`)
+	t.Setenv("QUOTES", `"say ""hi""",plain`)
+	t.Setenv("PATHS", `/a;"/b;c"`)
+	t.Setenv("NAIVE", `"echo a,b"`)
+
+	type Config struct {
+		Commands []string `csv:"true"`
+		Quotes   []string `csv:"true"`
+		Paths    []string `csv:"true" sep:";"`
+		Naive    []string
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Commands: []string{"echo a,b", "ls -l", "pwd"},
+		Quotes:   []string{`say "hi"`, "plain"},
+		Paths:    []string{"/a", "/b;c"},
+		Naive:    []string{`"echo a`, `b"`},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %q; expected %q", config, expect)
+	}
+
+	dumped := Dump(config)
+	if dumped["COMMANDS"] != `"echo a,b",ls -l,pwd` || dumped["QUOTES"] != `"say ""hi""",plain` {
+		t.Errorf("Dump() = %q; expected CSV records", dumped)
+	}
+
+	t.Setenv("COMMANDS", `"unterminated`)
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "COMMANDS" {
+		t.Errorf("FillE() error = %v; expected a FieldError for COMMANDS", err)
+	}
+}
+
+func TestFillJSON(t *testing.T) {
+	t.Setenv("CONFIG_JSON", `{"Host":"json.example.com","Port":9000,"DB":{"Name":"json_db"}}`)
+	t.Setenv("PORT", "9090")
+	t.Setenv("DB_USER", "env_user")
+
+	type Config struct {
+		Host  string `required:"true"`
+		Port  int
+		Debug bool   `default:"true"`
+		Name  string `default:"app"`
+		DB    struct {
+			Name string `default:"default_db"`
+			User string
+		}
+	}
+
+	var config Config
+	if err := FillJSON(&config, "CONFIG_JSON"); err != nil {
+		t.Fatalf("FillJSON() error = %v", err)
+	}
+
+	var expect Config
+	expect.Host = "json.example.com"
+	expect.Port = 9090
+	expect.Debug = true
+	expect.Name = "app"
+	expect.DB.Name = "json_db"
+	expect.DB.User = "env_user"
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillJSON() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillJSONMissing(t *testing.T) {
+	t.Setenv("PORT", "9090")
+
+	var config struct {
+		Port int
+		Host string `default:"localhost"`
+	}
+	if err := FillJSON(&config, "CONFIG_JSON"); err != nil {
+		t.Fatalf("FillJSON() error = %v", err)
+	}
+	if config.Port != 9090 || config.Host != "localhost" {
+		t.Errorf("FillJSON() = %+v; expected the variables and defaults to be used", config)
+	}
+
+	t.Setenv("CONFIG_JSON", "{")
+	var fieldErr *FieldError
+	if err := FillJSON(&config, "CONFIG_JSON"); !errors.As(err, &fieldErr) || fieldErr.Key != "CONFIG_JSON" {
+		t.Errorf("FillJSON() error = %v; expected a FieldError for CONFIG_JSON", err)
+	}
+}
+
+func TestFillJSONTags(t *testing.T) {
+	t.Setenv("DB_NAME", "test_db")
+	t.Setenv("MAX_CONNS", "10")
+	t.Setenv("HOST", "localhost")
+	t.Setenv("SKIPPED", "value")
+	t.Setenv("OVERRIDE", "env")
+	t.Setenv("TIMEOUT", "5s")
+
+	type Config struct {
+		Database string        `json:"db_name"`
+		MaxConns int           `json:"max-conns,omitempty"`
+		Host     string        `json:",omitempty"`
+		Skipped  string        `json:"-"`
+		Name     string        `json:"name" env:"OVERRIDE"`
+		Timeout  time.Duration `json:"-" env:"TIMEOUT"`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Database: "test_db",
+		MaxConns: 10,
+		Host:     "localhost",
+		Name:     "env",
+		Timeout:  5 * time.Second,
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillURLValues(t *testing.T) {
+	t.Setenv("PARAMS", "a=1&b=2&b=3")
+
+	var config struct {
+		Params url.Values
+	}
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := url.Values{"a": {"1"}, "b": {"2", "3"}}
+	if !reflect.DeepEqual(config.Params, expect) {
+		t.Errorf("FillE() Params = %v; expected %v", config.Params, expect)
+	}
+	if got := Dump(config)["PARAMS"]; got != "a=1&b=2&b=3" {
+		t.Errorf("Dump() PARAMS = %q; expected %q", got, "a=1&b=2&b=3")
+	}
+
+	t.Setenv("PARAMS", "a=%zz")
+	var fieldErr *FieldError
+	if err := FillE(&config); !errors.As(err, &fieldErr) || fieldErr.Key != "PARAMS" {
+		t.Errorf("FillE() error = %v; expected a FieldError for PARAMS", err)
+	}
+}
+
+func TestFillMapMerge(t *testing.T) {
+	t.Setenv("LABELS", "team:core,env:prod")
+	t.Setenv("LIMITS", "a:1,b:x")
+
+	type Config struct {
+		Labels map[string]string
+		Limits map[string]int
+		Empty  map[string]string
+	}
+
+	config := Config{
+		Labels: map[string]string{"env": "dev", "region": "eu"},
+		Limits: map[string]int{"a": 0},
+	}
+	FillE(&config)
+
+	expect := Config{
+		Labels: map[string]string{"env": "prod", "region": "eu", "team": "core"},
+		Limits: map[string]int{"a": 0},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillPointerToSliceAndMap(t *testing.T) {
+	t.Setenv("TAGS", "a,b,c")
+	t.Setenv("LIMITS", "a:1,b:2")
+
+	type Config struct {
+		Tags          *[]string
+		Limits        *map[string]int
+		MissingTags   *[]string
+		MissingLimits *map[string]int
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	if config.Tags == nil || !reflect.DeepEqual(*config.Tags, []string{"a", "b", "c"}) {
+		t.Errorf("FillE() Tags = %v; expected a pointer to [a b c]", config.Tags)
+	}
+	if config.Limits == nil || !reflect.DeepEqual(*config.Limits, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("FillE() Limits = %v; expected a pointer to map[a:1 b:2]", config.Limits)
+	}
+	if config.MissingTags != nil || config.MissingLimits != nil {
+		t.Errorf("FillE() = %+v; expected missing pointers to stay nil", config)
+	}
+}
+
+func TestFillEnviron(t *testing.T) {
+	t.Setenv("HOST", "from_process")
+
+	environ := []string{
+		"PORT=8080",
+		"DB_NAME=first",
+		"DB_NAME=second",
+		"EQUALS=a=b",
+		"INVALID",
+	}
+
+	type Config struct {
+		Host   string
+		Port   int
+		Equals string
+		DB     struct {
+			Name string
+		}
+	}
+
+	var config Config
+	FillEnviron(&config, environ)
+
+	var expect Config
+	expect.Port = 8080
+	expect.Equals = "a=b"
+	expect.DB.Name = "second"
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillEnviron() = %+v; expected %+v", config, expect)
+	}
+}
+
+type handler interface {
+	Name() string
+}
+
+type webhook struct {
+	URL     string
+	Retries int
+}
+
+func (w webhook) Name() string { return "webhook" }
+
+func TestFillInterface(t *testing.T) {
+	t.Setenv("POINTER_URL", "https://example.com/hook")
+	t.Setenv("POINTER_RETRIES", "3")
+	t.Setenv("VALUE_URL", "https://example.org/hook")
+	t.Setenv("EMPTY_URL", "ignored")
+
+	type Config struct {
+		Pointer handler
+		Value   handler
+		Empty   handler
+	}
+
+	config := Config{
+		Pointer: &webhook{Retries: 1},
+		Value:   webhook{Retries: 2},
+	}
+	if err := FillStrict(&config); err != nil {
+		t.Fatalf("FillStrict() error = %v", err)
+	}
+
+	expect := Config{
+		Pointer: &webhook{URL: "https://example.com/hook", Retries: 3},
+		Value:   webhook{URL: "https://example.org/hook", Retries: 2},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillStrict() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillWithOptions(t *testing.T) {
+	env := map[string]string{
+		"APP_HOSTS": "a.example.com;b.example.com",
+		"APP_PORTS": "80,443",
+		"APP_TAGS":  "env=prod;team=core",
+	}
+
+	type Config struct {
+		Hosts []string
+		Ports []int `sep:","`
+		Tags  map[string]string
+	}
+
+	var config Config
+	err := FillWithOptions(&config, Options{
+		Prefix:   "APP",
+		Lookup:   mapLookup(env),
+		Strict:   true,
+		SliceSep: ";",
+		MapSep:   "=",
+	})
+	if err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	expect := Config{
+		Hosts: []string{"a.example.com", "b.example.com"},
+		Ports: []int{80, 443},
+		Tags:  map[string]string{"env": "prod", "team": "core"},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillWithOptionsCaseInsensitive(t *testing.T) {
+	t.Setenv("db_name", "app")
+
+	var config struct {
+		DBName string
+	}
+	if err := FillWithOptions(&config, Options{CaseInsensitive: true}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if config.DBName != "app" {
+		t.Errorf("DBName = %q; expected %q", config.DBName, "app")
+	}
+
+	err := FillWithOptions(&config, Options{CaseInsensitive: true, Lookup: os.LookupEnv})
+	if err == nil {
+		t.Errorf("FillWithOptions() error = nil; expected an error for CaseInsensitive with Lookup")
+	}
+}
+
+func TestFillChar(t *testing.T) {
+	t.Setenv("DELIM", "|")
+	t.Setenv("QUOTE", "'")
+	t.Setenv("SYMBOL", "€")
+	t.Setenv("CODE", "124")
+	t.Setenv("SEPARATORS", "|,;")
+
+	type Config struct {
+		Delim      rune   `char:"true"`
+		Quote      byte   `char:"true"`
+		Symbol     rune   `char:"true"`
+		Code       int32  // Without the tag a rune is a number
+		Separators []rune `char:"true"`
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Delim:      '|',
+		Quote:      '\'',
+		Symbol:     '€',
+		Code:       124,
+		Separators: []rune{'|', ';'},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+	if dumped := Dump(&config); dumped["DELIM"] != "|" || dumped["SYMBOL"] != "€" || dumped["CODE"] != "124" {
+		t.Errorf("Dump() = %v; expected DELIM=|, SYMBOL=€ and CODE=124", dumped)
+	}
+}
+
+func TestFillCharErrors(t *testing.T) {
+	for _, value := range []string{"", "ab", "€"} {
+		t.Run(value, func(t *testing.T) {
+			var config struct {
+				Delim byte `char:"true"`
+			}
+			err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"DELIM": value})})
+			if err == nil {
+				t.Errorf("FillWithOptions() error = nil; expected an error for %q", value)
+			}
+		})
+	}
+}
+
+func TestFillSliceOfPointers(t *testing.T) {
+	t.Setenv("NUMS", "1,2,3")
+	t.Setenv("NAMES", "a,,c")
+	t.Setenv("LEVELS", "debug,error")
+
+	type Config struct {
+		Nums   []*int
+		Names  []*string
+		Levels []*logLevel
+	}
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	one, two, three := 1, 2, 3
+	debug, errorLevel := logLevel(0), logLevel(2)
+	expect := Config{
+		Nums:   []*int{&one, &two, &three},
+		Names:  []*string{strPtr("a"), strPtr(""), strPtr("c")},
+		Levels: []*logLevel{&debug, &errorLevel},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillSliceOfPointersEmptyElement(t *testing.T) {
+	t.Setenv("NUMS", "1,,3")
+
+	var config struct {
+		Nums []*int
+	}
+	if err := FillE(&config); err == nil {
+		t.Errorf("FillE() error = nil; expected an error for the empty element")
+	}
+	if config.Nums != nil {
+		t.Errorf("Nums = %v; expected it to be left untouched", config.Nums)
+	}
+}
+
+func TestFillEmptyBool(t *testing.T) {
+	t.Setenv("DEBUG", "")
+
+	tests := []struct {
+		tag     reflect.StructTag
+		initial bool
+		expect  bool
+		err     bool
+	}{
+		{tag: ``, expect: true},
+		{tag: `emptybool:"true"`, expect: true},
+		{tag: `emptybool:"false"`, initial: true, expect: false},
+		{tag: `emptybool:"error"`, initial: true, expect: true, err: true},
+		{tag: `emptybool:"maybe"`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.tag), func(t *testing.T) {
+			debug := tt.initial
+			err := (&filler{}).setFieldValue(reflect.ValueOf(&debug).Elem(), "", tt.tag)
+			if (err != nil) != tt.err {
+				t.Errorf("setFieldValue() error = %v; expected error %v", err, tt.err)
+			}
+			if debug != tt.expect {
+				t.Errorf("setFieldValue() = %v; expected %v", debug, tt.expect)
+			}
+		})
+	}
+
+	var config struct {
+		Debug bool
+	}
+	Fill(&config)
+	if !config.Debug {
+		t.Errorf("Fill() Debug = false; expected true for DEBUG=")
+	}
+}
+
+func TestFillSection(t *testing.T) {
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("DB_HOST", "db.example.com")
+	t.Setenv("DB_POOL_SIZE", "10")
+	t.Setenv("NAME", "ignored")
+
+	type Database struct {
+		Name string
+		Host string
+		Pool struct {
+			Size int
+		}
+	}
+	type Config struct {
+		DB Database
+	}
+
+	var db Database
+	FillSection(&db, "DB")
+
+	var config Config
+	Fill(&config)
+	if !reflect.DeepEqual(db, config.DB) {
+		t.Errorf("FillSection() = %+v; expected %+v", db, config.DB)
+	}
+	if db.Name != "app" || db.Pool.Size != 10 {
+		t.Errorf("FillSection() = %+v; expected Name=app and Pool.Size=10", db)
+	}
+
+	var pool struct {
+		Size int
+	}
+	FillSection(&pool, "DB.Pool")
+	if pool.Size != 10 {
+		t.Errorf("FillSection() = %+v; expected the size of DB_POOL_SIZE", pool)
+	}
+
+	t.Setenv("PRIMARY_DATABASE_NAME", "primary")
+	var primary Database
+	FillSection(&primary, "PrimaryDatabase")
+	if primary.Name != "primary" {
+		t.Errorf("FillSection() = %+v; expected the name of PRIMARY_DATABASE_NAME", primary)
+	}
+}
+
+func TestFillMapInvalidPairs(t *testing.T) {
+	t.Setenv("NAMES", "x:1,2:b")
+	t.Setenv("LIMITS", "a:1,b:x")
+
+	config := struct {
+		Names  map[int]string
+		Limits map[string]int
+	}{
+		Limits: map[string]int{"c": 3},
+	}
+	err := FillE(&config)
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("FillE() error = %v; expected errors for NAMES and LIMITS", err)
+	}
+	if msg := errs[0].Error(); !strings.Contains(msg, `invalid key in pair "x:1"`) {
+		t.Errorf("FillE() error = %v; expected it to report the pair x:1", msg)
+	}
+	if msg := errs[1].Error(); !strings.Contains(msg, `invalid value in pair "b:x"`) {
+		t.Errorf("FillE() error = %v; expected it to report the pair b:x", msg)
+	}
+	if config.Names != nil || !reflect.DeepEqual(config.Limits, map[string]int{"c": 3}) {
+		t.Errorf("FillE() = %+v; expected the maps to be left untouched", config)
+	}
+}
+
+func TestFillLines(t *testing.T) {
+	t.Setenv("ORIGINS", "https://a.example.com\nhttps://b.example.com,c\n\n")
+	t.Setenv("WINDOWS", "a\r\nb\r\n")
+	t.Setenv("PORTS", "80\n443")
+	t.Setenv("LABELS", "env:prod\r\nteam:core\n")
+	t.Setenv("BLANK", "\n")
+
+	type Config struct {
+		Origins []string          `lines:"true"`
+		Windows []string          `sep:"\n"`
+		Ports   []int             `lines:"true"`
+		Labels  map[string]string `lines:"true"`
+		Blank   []string          `lines:"true"`
+	}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{SliceSep: ";"}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	expect := Config{
+		Origins: []string{"https://a.example.com", "https://b.example.com,c"},
+		Windows: []string{"a", "b"},
+		Ports:   []int{80, 443},
+		Labels:  map[string]string{"env": "prod", "team": "core"},
+		Blank:   []string{},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+	if dumped := Dump(&config); dumped["PORTS"] != "80\n443" {
+		t.Errorf("Dump() = %q; expected PORTS=80\\n443", dumped["PORTS"])
+	}
+}
+
+func TestFillInvalidDest(t *testing.T) {
+	type Config struct {
+		Name string
+	}
+	var nilConfig *Config
+	name := "app"
+
+	tests := []struct {
+		name   string
+		dest   interface{}
+		expect string
+	}{
+		{
+			name:   "value",
+			dest:   Config{},
+			expect: "lazyenv: Fill requires a non-nil pointer to a struct, got lazyenv.Config",
+		},
+		{
+			name:   "nil pointer",
+			dest:   nilConfig,
+			expect: "lazyenv: Fill requires a non-nil pointer to a struct, got a nil *lazyenv.Config",
+		},
+		{
+			name:   "nil",
+			dest:   nil,
+			expect: "lazyenv: Fill requires a non-nil pointer to a struct, got nil",
+		},
+		{
+			name:   "pointer to string",
+			dest:   &name,
+			expect: "lazyenv: Fill requires a non-nil pointer to a struct, got *string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := FillE(tt.dest); err == nil || err.Error() != tt.expect {
+				t.Errorf("FillE() error = %v; expected %s", err, tt.expect)
+			}
+
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || err.Error() != tt.expect {
+					t.Errorf("Fill() panic = %v; expected %s", err, tt.expect)
+				}
+			}()
+			Fill(tt.dest)
+		})
+	}
+}
+
+func TestFillKey(t *testing.T) {
+	t.Setenv("ALLOWED_HOSTS", "a.example.com,b.example.com")
+	t.Setenv("FLAGS", "beta:true,dark_mode:false")
+	t.Setenv("PORT", "http")
+
+	var hosts []string
+	if err := FillKey(&hosts, "ALLOWED_HOSTS"); err != nil {
+		t.Fatalf("FillKey() error = %v", err)
+	}
+	if expect := []string{"a.example.com", "b.example.com"}; !reflect.DeepEqual(hosts, expect) {
+		t.Errorf("FillKey() = %v; expected %v", hosts, expect)
+	}
+
+	flags := map[string]bool{"legacy": true}
+	if err := FillKey(&flags, "FLAGS"); err != nil {
+		t.Fatalf("FillKey() error = %v", err)
+	}
+	if expect := map[string]bool{"legacy": true, "beta": true, "dark_mode": false}; !reflect.DeepEqual(flags, expect) {
+		t.Errorf("FillKey() = %v; expected %v", flags, expect)
+	}
+
+	missing := []string{"kept"}
+	if err := FillKey(&missing, "MISSING"); err != nil || len(missing) != 1 {
+		t.Errorf("FillKey() = %v, %v; expected the slice to be left untouched", missing, err)
+	}
+
+	port := 8080
+	var fieldErr *FieldError
+	if err := FillKey(&port, "PORT"); !errors.As(err, &fieldErr) || fieldErr.Key != "PORT" || port != 8080 {
+		t.Errorf("FillKey() = %d, %v; expected a FieldError for PORT", port, err)
+	}
+	if err := FillKey(hosts, "ALLOWED_HOSTS"); err == nil {
+		t.Errorf("FillKey() error = nil; expected an error for a non-pointer")
+	}
+}
+
+func TestFillMap(t *testing.T) {
+	t.Setenv("FEATURE_BETA", "true")
+	t.Setenv("FEATURE_DARK_MODE", "off")
+	t.Setenv("FEATURE_BROKEN", "maybe")
+	t.Setenv("FEATURES", "ignored")
+
+	var features map[string]bool
+	err := FillMap(&features, "FEATURE")
+
+	if expect := map[string]bool{"BETA": true, "DARK_MODE": false}; !reflect.DeepEqual(features, expect) {
+		t.Errorf("FillMap() = %v; expected %v", features, expect)
+	}
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "FEATURE_BROKEN" {
+		t.Errorf("FillMap() error = %v; expected an error for FEATURE_BROKEN", err)
+	}
+
+	var notMap []string
+	expect := "lazyenv: FillMap requires a non-nil pointer to a map, got *[]string"
+	if err := FillMap(&notMap, "FEATURE"); err == nil || err.Error() != expect {
+		t.Errorf("FillMap() error = %v; expected %s", err, expect)
+	}
+}
+
+func TestFillTruthy(t *testing.T) {
+	tests := []struct {
+		value  string
+		tag    reflect.StructTag
+		expect bool
+		err    bool
+	}{
+		{value: "2", tag: `truthy:"int"`, expect: true},
+		{value: "-1", tag: `truthy:"int"`, expect: true},
+		{value: "0", tag: `truthy:"int"`, expect: false},
+		{value: "00", tag: `truthy:"int"`, expect: false},
+		{value: "on", tag: `truthy:"int"`, expect: true},
+		{value: "maybe", tag: `truthy:"int"`, err: true},
+		{value: "2", err: true},
+		{value: "1", tag: `truthy:"yes"`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+string(tt.tag), func(t *testing.T) {
+			var flag bool
+			err := (&filler{}).setFieldValue(reflect.ValueOf(&flag).Elem(), tt.value, tt.tag)
+			if (err != nil) != tt.err {
+				t.Errorf("setFieldValue() error = %v; expected error %v", err, tt.err)
+			}
+			if flag != tt.expect {
+				t.Errorf("setFieldValue() = %v; expected %v", flag, tt.expect)
+			}
+		})
+	}
+}
+
+func TestNameStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy func(string) string
+		input    string
+		expected string
+	}{
+		{"screaming snake", ScreamingSnakeCase, "DBName", "DB_NAME"},
+		{"screaming snake", ScreamingSnakeCase, "HTTPServer", "HTTP_SERVER"},
+		{"upper", UpperCase, "DBName", "DBNAME"},
+		{"upper", UpperCase, "HTTPServer", "HTTPSERVER"},
+		{"lower snake", LowerSnakeCase, "DBName", "db_name"},
+		{"lower snake", LowerSnakeCase, "OAuth2Token", "oauth2_token"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+"/"+test.input, func(t *testing.T) {
+			if result := test.strategy(test.input); result != test.expected {
+				t.Errorf("%s(%s) = %s; expected %s", test.name, test.input, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestSetNameStrategy(t *testing.T) {
+	type Database struct {
+		HostName string
+	}
+	type Config struct {
+		DBName   string
+		Port     int `env:"PORT"`
+		Database Database
+	}
+
+	SetNameStrategy(LowerSnakeCase)
+	defer SetNameStrategy(nil)
+
+	var config Config
+	env := map[string]string{"db_name": "app", "PORT": "80", "database_host_name": "localhost"}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	expect := Config{DBName: "app", Port: 80, Database: Database{HostName: "localhost"}}
+	if config != expect {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+
+	SetNameStrategy(nil)
+	if name := convertName("DBName"); name != "DB_NAME" {
+		t.Errorf("convertName(DBName) = %s after restoring the default; expected DB_NAME", name)
+	}
+}
+
+func TestFillPointerToPointer(t *testing.T) {
+	var config struct {
+		N       **int
+		Name    **string
+		Level   ***int `default:"3"`
+		Missing **int
+	}
+	env := map[string]string{"N": "5", "NAME": "app"}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	if config.N == nil || *config.N == nil || **config.N != 5 {
+		t.Errorf("N = %v; expected a pointer to a pointer to 5", config.N)
+	}
+	if config.Name == nil || *config.Name == nil || **config.Name != "app" {
+		t.Errorf("Name = %v; expected a pointer to a pointer to app", config.Name)
+	}
+	if config.Level == nil || *config.Level == nil || **config.Level == nil || ***config.Level != 3 {
+		t.Errorf("Level = %v; expected three pointers to 3", config.Level)
+	}
+	if config.Missing != nil {
+		t.Errorf("Missing = %v; expected nil", config.Missing)
+	}
+}
+
+func TestFillOmitEmpty(t *testing.T) {
+	type Config struct {
+		Host    string `omitempty:"true"`
+		Port    int    `omitempty:"true" default:"80"`
+		Name    string `env:"NAME,APP_NAME" omitempty:"true"`
+		Comment string
+	}
+	config := Config{Host: "localhost", Comment: "preset"}
+	env := map[string]string{"HOST": "", "PORT": "", "NAME": "", "APP_NAME": "app", "COMMENT": ""}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	expect := Config{Host: "localhost", Port: 80, Name: "app", Comment: ""}
+	if config != expect {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillIndexedSlice(t *testing.T) {
+	type Server struct {
+		Host string
+		Port int `default:"80"`
+	}
+	type Config struct {
+		Servers []Server
+		Backups []*Server
+	}
+
+	t.Setenv("SERVERS_0_HOST", "a.example.com")
+	t.Setenv("SERVERS_0_PORT", "8080")
+	t.Setenv("SERVERS_1_HOST", "b.example.com")
+	t.Setenv("BACKUPS_2_HOST", "backup.example.com")
+
+	var config Config
+	if err := FillE(&config); err != nil {
+		t.Fatalf("FillE() error = %v", err)
+	}
+
+	expect := Config{
+		Servers: []Server{{Host: "a.example.com", Port: 8080}, {Host: "b.example.com", Port: 80}},
+		Backups: []*Server{{Port: 80}, {Port: 80}, {Host: "backup.example.com", Port: 80}},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillE() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillIndexedSliceBounds(t *testing.T) {
+	type Server struct {
+		Host string
+	}
+	type Config struct {
+		Servers []Server
+		Backups []Server
+	}
+
+	t.Setenv("SERVERS_99999999999_HOST", "x")
+	t.Setenv("BACKUPS_0_HOST", "a")
+	t.Setenv("BACKUPS_7_UNKNOWN", "ignored")
+
+	var config Config
+	err := FillE(&config)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "SERVERS_99999999999_HOST" || !errors.Is(err, ErrConvert) {
+		t.Errorf("FillE() error = %v; expected an ErrConvert error for SERVERS_99999999999_HOST", err)
+	}
+	if config.Servers != nil || !reflect.DeepEqual(config.Backups, []Server{{Host: "a"}}) {
+		t.Errorf("FillE() = %+v; expected no servers and the variables of the Backups fields only", config)
+	}
+}
+
+func TestFillIndexedSliceEnviron(t *testing.T) {
+	type Server struct {
+		Host string
+	}
+	var config struct {
+		Servers []Server
+	}
+
+	// The entries of FillEnviron can be listed, so the gaps are zero elements like in Fill
+	FillEnviron(&config, []string{"SERVERS_0_HOST=a", "SERVERS_2_HOST=c"})
+	expect := []Server{{Host: "a"}, {}, {Host: "c"}}
+	if !reflect.DeepEqual(config.Servers, expect) {
+		t.Errorf("Servers = %+v; expected %+v", config.Servers, expect)
+	}
+}
+
+func TestFillIndexedSliceLookup(t *testing.T) {
+	type Server struct {
+		Host string
+		Port int
+	}
+	var config struct {
+		Servers []Server
+	}
+
+	// A custom lookup can't be listed, so the indices are probed and stop at the first gap
+	env := map[string]string{"SERVERS_0_HOST": "a", "SERVERS_1_PORT": "81", "SERVERS_3_HOST": "d"}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	expect := []Server{{Host: "a"}, {Port: 81}}
+	if !reflect.DeepEqual(config.Servers, expect) {
+		t.Errorf("Servers = %+v; expected %+v", config.Servers, expect)
+	}
+}
+
+func TestFillIndexedSliceJSON(t *testing.T) {
+	type Server struct {
+		Host string
+	}
+	var config struct {
+		Servers []Server
+	}
+	env := map[string]string{"SERVERS": `[{"Host":"json"}]`, "SERVERS_0_HOST": "indexed"}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if len(config.Servers) != 1 || config.Servers[0].Host != "json" {
+		t.Errorf("Servers = %+v; expected the JSON value to take precedence", config.Servers)
+	}
+}
+
+func TestFillTransform(t *testing.T) {
+	type Config struct {
+		Email   string   `transform:"lower"`
+		Code    string   `transform:"upper"`
+		Name    string   `transform:"title"`
+		Token   string   `transform:"trimspace"`
+		Chained string   `transform:"trimspace,lower"`
+		Tags    []string `transform:"upper"`
+		Plain   string
+	}
+	env := map[string]string{
+		"EMAIL":   "John.Doe@Example.COM",
+		"CODE":    "es-mx",
+		"NAME":    "jane  van doe",
+		"TOKEN":   "  abc\n",
+		"CHAINED": "  Admin@Example.com ",
+		"TAGS":    "a,b",
+		"PLAIN":   " Mixed Case ",
+	}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	expect := Config{
+		Email:   "john.doe@example.com",
+		Code:    "ES-MX",
+		Name:    "Jane  Van Doe",
+		Token:   "abc",
+		Chained: "admin@example.com",
+		Tags:    []string{"A", "B"},
+		Plain:   " Mixed Case ",
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillTransformUnknown(t *testing.T) {
+	var config struct {
+		Name string `transform:"reverse"`
+	}
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"NAME": "x"})})
+	if err == nil || !strings.Contains(err.Error(), `unknown transform "reverse"`) {
+		t.Errorf("FillWithOptions() error = %v; expected an unknown transform error", err)
+	}
+}
+
+func TestFillFromFile(t *testing.T) {
+	dir := t.TempDir()
+	password := filepath.Join(dir, "db_password")
+	if err := os.WriteFile(password, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("abc\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct {
+		DBPassword string
+		Token      string `env:"TOKEN,API_TOKEN"`
+		User       string
+		Missing    string
+	}
+	env := map[string]string{
+		"DB_PASSWORD_FILE": password,
+		"API_TOKEN_FILE":   token,
+		"USER":             "admin",
+		"USER_FILE":        password,
+		"MISSING_FILE":     filepath.Join(dir, "missing"),
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	err := FillE(&config)
+
+	if config.DBPassword != "s3cr3t" || config.Token != "abc" || config.User != "admin" {
+		t.Errorf("FillE() = %+v; expected the values of the files, and the variable over its file", config)
+	}
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "MISSING_FILE" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("FillE() error = %v; expected a not exist error for MISSING_FILE", err)
+	}
+}
+
+func TestFillFromFileCustomLookup(t *testing.T) {
+	password := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(password, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct {
+		DBPassword string
+		DB         *struct {
+			Host string
+		}
+	}
+	var looked []string
+	lookup := func(key string) (string, bool) {
+		looked = append(looked, key)
+		return mapLookup(map[string]string{"DB_PASSWORD_FILE": password, "DB_HOST_FILE": password})(key)
+	}
+	if err := FillWithOptions(&config, Options{Lookup: lookup}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if config.DBPassword != "" || config.DB != nil {
+		t.Errorf("FillWithOptions() = %+v; expected the _FILE variables of a custom lookup to be ignored", config)
+	}
+	expect := []string{"DB_PASSWORD", "DB_HOST"}
+	if !reflect.DeepEqual(looked, expect) {
+		t.Errorf("FillWithOptions() looked up %v; expected %v", looked, expect)
+	}
+}
+
+func TestFillContext(t *testing.T) {
+	type Config struct {
+		Host     string
+		Password string
+		Port     int `default:"80"`
+	}
+	env := map[string]string{"HOST": "localhost", "PASSWORD": "s3cr3t", "PORT": "8080"}
+
+	var config Config
+	err := FillContext(context.Background(), &config, func(ctx context.Context, key string) (string, bool, error) {
+		value, ok := env[key]
+		return value, ok, nil
+	})
+	if err != nil {
+		t.Fatalf("FillContext() error = %v", err)
+	}
+	if expect := (Config{Host: "localhost", Password: "s3cr3t", Port: 8080}); config != expect {
+		t.Errorf("FillContext() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillContextCancel(t *testing.T) {
+	type Config struct {
+		Host     string
+		Password string
+		Port     int `default:"80"`
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := Config{Port: 443}
+	err := FillContext(ctx, &config, func(ctx context.Context, key string) (string, bool, error) {
+		if key == "PASSWORD" {
+			// The secret store hangs until the context is cancelled
+			cancel()
+			<-ctx.Done()
+			return "", false, ctx.Err()
+		}
+		return "localhost", true, nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FillContext() error = %v; expected %v", err, context.Canceled)
+	}
+	if expect := (Config{Host: "localhost", Port: 443}); config != expect {
+		t.Errorf("FillContext() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillContextLookupError(t *testing.T) {
+	var config struct {
+		Host string
+	}
+	lookupErr := errors.New("connection refused")
+	err := FillContext(context.Background(), &config, func(ctx context.Context, key string) (string, bool, error) {
+		return "", false, lookupErr
+	})
+	if !errors.Is(err, lookupErr) || !strings.Contains(err.Error(), "HOST") {
+		t.Errorf("FillContext() error = %v; expected the lookup error for HOST", err)
+	}
+}
+
+func TestFillPercent(t *testing.T) {
+	tests := []struct {
+		value  string
+		expect float64
+		ok     bool
+	}{
+		{"5%", 0.05, true},
+		{"100%", 1, true},
+		{"12.5 %", 0.125, true},
+		{"0.5", 0.5, true},
+		{"five%", 0, false},
+		{"5%%", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var config struct {
+				SampleRate float64 `percent:"true"`
+			}
+			err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"SAMPLE_RATE": tt.value})})
+			if (err == nil) != tt.ok {
+				t.Fatalf("FillWithOptions() error = %v; expected success %v", err, tt.ok)
+			}
+			if math.Abs(config.SampleRate-tt.expect) > 1e-9 {
+				t.Errorf("SampleRate = %v; expected %v", config.SampleRate, tt.expect)
+			}
+		})
+	}
+}
+
+func TestFillDeprecated(t *testing.T) {
+	var warnings []string
+	SetLogger(func(format string, v ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, v...))
+	})
+	defer SetLogger(nil)
+
+	var config struct {
+		Host    string `env:"HOST,OLD_HOST" deprecated:"use HOST instead"`
+		Port    int    `deprecated:"use LISTEN instead" default:"80"`
+		Timeout int    `deprecated:"it is ignored"`
+	}
+	env := map[string]string{"OLD_HOST": "localhost"}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	expect := []string{"lazyenv: OLD_HOST is deprecated: use HOST instead"}
+	if !reflect.DeepEqual(warnings, expect) {
+		t.Errorf("warnings = %q; expected %q", warnings, expect)
+	}
+	if config.Host != "localhost" || config.Port != 80 {
+		t.Errorf("FillWithOptions() = %+v; expected deprecated fields to be filled", config)
+	}
+}
+
+// logMode is a custom flag.Value, to test that Fill uses Set.
+type logMode int
+
+func (m *logMode) Set(value string) error {
+	switch value {
+	case "quiet":
+		*m = 1
+	case "verbose":
+		*m = 2
+	default:
+		return fmt.Errorf("unknown mode %q", value)
+	}
+	return nil
+}
+
+func (m *logMode) String() string {
+	return strconv.Itoa(int(*m))
+}
+
+func TestFillFlagValue(t *testing.T) {
+	type Config struct {
+		Mode     logMode
+		Fallback *logMode
+		Modes    []logMode
+	}
+	env := map[string]string{"MODE": "verbose", "FALLBACK": "quiet", "MODES": "quiet,verbose"}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if config.Mode != 2 || config.Fallback == nil || *config.Fallback != 1 || !reflect.DeepEqual(config.Modes, []logMode{1, 2}) {
+		t.Errorf("FillWithOptions() = %+v; expected the values set with Set", config)
+	}
+
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"MODE": "loud"})})
+	if err == nil || !strings.Contains(err.Error(), `unknown mode "loud"`) {
+		t.Errorf("FillWithOptions() error = %v; expected the error of Set", err)
+	}
+}
+
+func TestFillSources(t *testing.T) {
+	var config struct {
+		Host  string
+		Port  int
+		Debug bool
+		Name  string `default:"app"`
+	}
+	overrides := map[string]string{"PORT": "9090"}
+	dotenv := map[string]string{"HOST": "dotenv.local", "PORT": "8080", "DEBUG": "true"}
+	environ := map[string]string{"HOST": "env.local", "DEBUG": "false"}
+
+	var looked []string
+	last := func(key string) (string, bool) {
+		looked = append(looked, key)
+		return "", false
+	}
+	FillSources(&config, mapLookup(overrides), mapLookup(dotenv), mapLookup(environ), last)
+
+	if config.Host != "dotenv.local" || config.Port != 9090 || !config.Debug || config.Name != "app" {
+		t.Errorf("FillSources() = %+v; expected the values of the first source that has each key", config)
+	}
+	for _, key := range looked {
+		if key == "HOST" || key == "PORT" || key == "DEBUG" {
+			t.Errorf("FillSources() looked up %s in the last source; expected it to stop at the first hit", key)
+		}
+	}
+}
+
+func TestFillTrimPrefixSuffix(t *testing.T) {
+	type Config struct {
+		Version string `trimprefix:"v"`
+		Name    string `trimsuffix:"-AbCdEf"`
+		Quoted  string `trimprefix:"'" trimsuffix:"'"`
+		Tag     string `trimprefix:"v"`
+		Release string `trimprefix:"release-" transform:"upper"`
+	}
+	env := map[string]string{
+		"VERSION": "v1.2.3",
+		"NAME":    "DB_PASS-AbCdEf",
+		"QUOTED":  "'value'",
+		"TAG":     "1.0",
+		"RELEASE": "release-rc1",
+	}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	expect := Config{Version: "1.2.3", Name: "DB_PASS", Quoted: "value", Tag: "1.0", Release: "RC1"}
+	if config != expect {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+}
+
+func TestFillNilNestedPointer(t *testing.T) {
+	type DBConfig struct {
+		Host string `default:"localhost"`
+		Port int    `required:"true"`
+	}
+	type Config struct {
+		Name string
+		DB   *DBConfig
+	}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"NAME": "app"})}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v; expected the required field of the unset struct to be ignored", err)
+	}
+	if config.DB != nil {
+		t.Errorf("DB = %+v; expected nil without DB_* variables", config.DB)
+	}
+
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"DB_PORT": "5432"})}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if config.DB == nil || *config.DB != (DBConfig{Host: "localhost", Port: 5432}) {
+		t.Errorf("DB = %+v; expected it to be allocated and filled when DB_PORT is set", config.DB)
+	}
+}
+
+func TestFillNilNestedPointerIndexed(t *testing.T) {
+	type Server struct {
+		Host string
+	}
+	type DBConfig struct {
+		Servers []Server
+	}
+	var config struct {
+		DB *DBConfig
+	}
+
+	// The environment is listed, while the custom lookup is probed from index 0
+	t.Setenv("DB_SERVERS_0_HOST", "db1")
+	for _, opts := range []Options{{}, {Lookup: mapLookup(map[string]string{"DB_SERVERS_0_HOST": "db1"})}} {
+		config.DB = nil
+		if err := FillWithOptions(&config, opts); err != nil {
+			t.Fatalf("FillWithOptions() error = %v", err)
+		}
+		if config.DB == nil || !reflect.DeepEqual(config.DB.Servers, []Server{{Host: "db1"}}) {
+			t.Errorf("DB = %+v; expected it to be allocated for DB_SERVERS_0_HOST", config.DB)
+		}
+	}
+}
+
+func TestFillNestedSep(t *testing.T) {
+	type Pool struct {
+		MaxSize int
+	}
+	type Database struct {
+		Host string
+		Pool *Pool
+	}
+	type Config struct {
+		Name     string
+		Database Database
+	}
+
+	tests := []struct {
+		sep string
+		env map[string]string
+	}{
+		{"__", map[string]string{"APP_NAME": "app", "APP_DATABASE__HOST": "db", "APP_DATABASE__POOL__MAX_SIZE": "10", "APP_DATABASE_HOST": "wrong"}},
+		{".", map[string]string{"APP_NAME": "app", "APP_DATABASE.HOST": "db", "APP_DATABASE.POOL.MAX_SIZE": "10", "APP_DATABASE_HOST": "wrong"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sep, func(t *testing.T) {
+			var config Config
+			err := FillWithOptions(&config, Options{Prefix: "APP", NestedSep: tt.sep, Lookup: mapLookup(tt.env)})
+			if err != nil {
+				t.Fatalf("FillWithOptions() error = %v", err)
+			}
+			if config.Name != "app" || config.Database.Host != "db" || config.Database.Pool == nil || config.Database.Pool.MaxSize != 10 {
+				t.Errorf("FillWithOptions() = %+v; expected the nested fields joined with %q", config, tt.sep)
+			}
+		})
+	}
+}
+
+func TestFillNestedSepIndexed(t *testing.T) {
+	type Server struct {
+		Host string
+	}
+	var config struct {
+		Servers []Server
+	}
+	env := map[string]string{"SERVERS__0__HOST": "a", "SERVERS__1__HOST": "b"}
+	if err := FillWithOptions(&config, Options{NestedSep: "__", Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	if expect := []Server{{Host: "a"}, {Host: "b"}}; !reflect.DeepEqual(config.Servers, expect) {
+		t.Errorf("Servers = %+v; expected %+v", config.Servers, expect)
+	}
+}
+
+func TestFillSet(t *testing.T) {
+	type Config struct {
+		Roles  map[string]struct{}
+		Ports  map[int]struct{}
+		Hosts  map[string]struct{} `sep:";" trim:"true"`
+		Empty  map[string]struct{}
+		Absent map[string]struct{}
+	}
+	env := map[string]string{"ROLES": "admin,user,admin", "PORTS": "80,443", "HOSTS": " a ; b ", "EMPTY": ""}
+
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	expect := Config{
+		Roles: map[string]struct{}{"admin": {}, "user": {}},
+		Ports: map[int]struct{}{80: {}, 443: {}},
+		Hosts: map[string]struct{}{"a": {}, "b": {}},
+		Empty: map[string]struct{}{},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(map[string]string{"PORTS": "80,http"})})
+	if err == nil || !strings.Contains(err.Error(), `invalid element "http"`) {
+		t.Errorf("FillWithOptions() error = %v; expected an invalid element error", err)
+	}
+	if dump := Dump(&Config{Roles: expect.Roles}); dump["ROLES"] != "admin,user" {
+		t.Errorf("Dump() ROLES = %q; expected admin,user", dump["ROLES"])
+	}
+}
+
+func TestFillValue(t *testing.T) {
+	type Config struct {
+		Host string
+		Port int
+	}
+	t.Setenv("MYAPP_HOST", "localhost")
+	t.Setenv("MYAPP_PORT", "80")
+
+	var config Config
+	if err := FillValue(reflect.ValueOf(&config).Elem(), "MYAPP"); err != nil {
+		t.Fatalf("FillValue() error = %v", err)
+	}
+	if config != (Config{Host: "localhost", Port: 80}) {
+		t.Errorf("FillValue() = %+v; expected the prefixed values", config)
+	}
+
+	var wrapper struct {
+		config Config
+	}
+	tests := []struct {
+		name   string
+		value  reflect.Value
+		expect string
+	}{
+		{"not addressable", reflect.ValueOf(config), "got a struct that can't be set"},
+		{"unexported field", reflect.ValueOf(&wrapper).Elem().Field(0), "got a struct that can't be set"},
+		{"not a struct", reflect.ValueOf(&config.Port).Elem(), "got int"},
+		{"invalid", reflect.Value{}, "got an invalid value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FillValue(tt.value, "")
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Errorf("FillValue() error = %v; expected %q", err, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSetTagKey(t *testing.T) {
+	type Database struct {
+		Host string `conf:"HOST"`
+	}
+	type Config struct {
+		Port     int      `conf:"PORT"`
+		Name     string   `conf:"APP_NAME,NAME"`
+		Ignored  string   `conf:"-"`
+		Other    string   `env:"OTHER_ENV"`
+		Database Database `conf:"DB"`
+	}
+
+	SetTagKey("conf")
+	defer SetTagKey("")
+
+	env := map[string]string{"PORT": "80", "NAME": "app", "IGNORED": "x", "OTHER": "other", "OTHER_ENV": "env", "DB_HOST": "db"}
+	var config Config
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	expect := Config{Port: 80, Name: "app", Other: "other", Database: Database{Host: "db"}}
+	if config != expect {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+
+	SetTagKey("")
+	if name := fieldEnvName(reflect.TypeOf(Config{}).Field(3)); name != "OTHER_ENV" {
+		t.Errorf("fieldEnvName(Other) = %s after restoring the default; expected OTHER_ENV", name)
+	}
+}
+
+func TestFillESentinelErrors(t *testing.T) {
+	type Config struct {
+		APIKey  string `required:"true"`
+		Port    int
+		Workers int `min:"1"`
+	}
+	env := map[string]string{"PORT": "eighty", "WORKERS": "0"}
+
+	var config Config
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(env)})
+	for _, sentinel := range []error{ErrRequired, ErrConvert, ErrValidate} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(%v, %v) = false; expected true", err, sentinel)
+		}
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("errors.As(%v, *FieldError) = false; expected true", err)
+	}
+	if fieldErr.Path != "APIKey" || fieldErr.Key != "API_KEY" || fieldErr.Kind != reflect.String || !errors.Is(fieldErr, ErrRequired) {
+		t.Errorf("errors.As() = %+v; expected the required error of APIKey", fieldErr)
+	}
+
+	var errs Errors
+	errors.As(err, &errs)
+	expect := []struct {
+		path, key, value string
+		kind             reflect.Kind
+		sentinel         error
+	}{
+		{"APIKey", "API_KEY", "", reflect.String, ErrRequired},
+		{"Port", "PORT", "eighty", reflect.Int, ErrConvert},
+		{"Workers", "WORKERS", "0", reflect.Int, ErrValidate},
+	}
+	if len(errs) != len(expect) {
+		t.Fatalf("FillWithOptions() returned %d errors; expected %d: %v", len(errs), len(expect), err)
+	}
+	for i, e := range expect {
+		got := errs[i]
+		if got.Path != e.path || got.Key != e.key || got.Value != e.value || got.Kind != e.kind {
+			t.Errorf("error %d = %+v; expected %s (%s) = %q", i, got, e.path, e.key, e.value)
+		}
+		for _, sentinel := range []error{ErrRequired, ErrConvert, ErrValidate} {
+			if is := errors.Is(got, sentinel); is != (sentinel == e.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = %t; expected %t", got, sentinel, is, !is)
+			}
+		}
+	}
+}
+
+func TestFillESentinelValidate(t *testing.T) {
+	var config validatedConfig
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(nil)})
+	if !errors.Is(err, ErrValidate) || errors.Is(err, ErrConvert) {
+		t.Errorf("FillWithOptions() error = %v; expected an ErrValidate error", err)
+	}
+}
+
+func TestFillESentinelConvert(t *testing.T) {
+	t.Setenv("CONFIG_JSON", "{")
+	var config struct {
+		Token string
+		Node  string `default:"{{.Env}}"`
+	}
+	if err := FillJSON(&config, "CONFIG_JSON"); !errors.Is(err, ErrConvert) {
+		t.Errorf("FillJSON() error = %v; expected an ErrConvert error for the JSON document", err)
+	}
+
+	t.Setenv("TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+	err := FillE(&config)
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || !errors.Is(errs[0], ErrConvert) || !errors.Is(errs[1], ErrConvert) {
+		t.Errorf("FillE() error = %v; expected ErrConvert errors for TOKEN_FILE and the default of Node", err)
+	}
+}
+
+func TestMustGetSentinelErrors(t *testing.T) {
+	t.Setenv("PORT", "eighty")
+
+	tests := []struct {
+		key      string
+		sentinel error
+	}{
+		{"PORT", ErrConvert},
+		{"MISSING_PORT", ErrRequired},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, tt.sentinel) {
+					t.Errorf("MustGetInt(%q) panic = %v; expected %v", tt.key, err, tt.sentinel)
+				}
+			}()
+			MustGetInt(tt.key)
+		}()
+	}
+}
+
+func TestFillWithOptionsPrefilled(t *testing.T) {
+	type Config struct {
+		Host string `default:"localhost"`
+		Port int    `default:"80"`
+		Name string `required:"true"`
+	}
+	config := Config{Host: "example.com", Name: "app"}
+	err := FillWithOptions(&config, Options{Prefilled: true, Lookup: mapLookup(map[string]string{"NAME": "override"})})
+	if err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+	expect := Config{Host: "example.com", Port: 80, Name: "override"}
+	if config != expect {
+		t.Errorf("FillWithOptions() = %+v; expected %+v", config, expect)
+	}
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,92 @@
+package lazyenv
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the multipliers of the byte size suffixes, in SI (powers of 1000) and binary (powers of 1024) form.
+var sizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// parseSize parses a human readable byte size like 10MB, 2GiB or 0.5GB into a number of bytes.
+// The suffixes are case insensitive and a number without suffix is a number of bytes.
+// The number can have a fraction as long as the result is a whole number of bytes, and a leading +,
+// but not a -, as the result is unsigned. parseSignedSize accepts negative sizes.
+func parseSize(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-") {
+		return 0, fmt.Errorf("negative size %q for an unsigned value", value)
+	}
+	value = strings.TrimPrefix(value, "+")
+	i := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(value)
+	}
+	number := value[:i]
+	if number == "" || number == "." || strings.Count(number, ".") > 1 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(value[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size suffix in %q", value)
+	}
+	whole, fraction, _ := strings.Cut(number, ".")
+	if strings.Trim(fraction, "0") == "" {
+		n, err := strconv.ParseUint(cmp.Or(whole, "0"), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxUint64/unit {
+			return 0, errors.New("size out of range")
+		}
+		return n * unit, nil
+	}
+
+	size, _ := new(big.Rat).SetString(number)
+	size.Mul(size, new(big.Rat).SetUint64(unit))
+	if !size.IsInt() {
+		return 0, fmt.Errorf("size %q is not a whole number of bytes", value)
+	}
+	if !size.Num().IsUint64() {
+		return 0, errors.New("size out of range")
+	}
+	return size.Num().Uint64(), nil
+}
+
+// parseSignedSize parses a byte size like parseSize, but also accepts negative sizes like -10MB.
+func parseSignedSize(value string) (int64, error) {
+	magnitude, negative := strings.CutPrefix(strings.TrimSpace(value), "-")
+	size, err := parseSize(magnitude)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		if size > -math.MinInt64 {
+			return 0, errors.New("size out of range")
+		}
+		return -int64(size), nil
+	}
+	if size > math.MaxInt64 {
+		return 0, errors.New("size out of range")
+	}
+	return int64(size), nil
+}
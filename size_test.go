@@ -0,0 +1,117 @@
+package lazyenv
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"10KB", 10000},
+		{"10MB", 10000000},
+		{"10mb", 10000000},
+		{"2GB", 2000000000},
+		{"1TB", 1000000000000},
+		{"10KiB", 10240},
+		{"10MiB", 10485760},
+		{"2GiB", 2147483648},
+		{"1 TiB", 1099511627776},
+		{"0.5GB", 500000000},
+		{"1.5KiB", 1536},
+		{".25MB", 250000},
+		{"+1KB", 1000},
+		{"2.0B", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := parseSize(test.input)
+			if err != nil || result != test.expected {
+				t.Errorf("parseSize(%s) = %d, %v; expected %d", test.input, result, err, test.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "MB", "10XB", "10 M B", "99999999999PiB", "-10MB", "1.5B", "1..5KB", ".KB", "99999999999.5PiB"} {
+		if _, err := parseSize(input); err == nil {
+			t.Errorf("parseSize(%q) returned no error", input)
+		}
+	}
+}
+
+func TestFillSize(t *testing.T) {
+	t.Setenv("MAX_BODY", "10MB")
+	t.Setenv("CACHE", "2GiB")
+	t.Setenv("BUFFER", "64KiB")
+
+	type Config struct {
+		MaxBody int    `size:"true"`
+		Cache   uint64 `size:"true"`
+		Buffer  int16  `size:"true"`
+	}
+
+	var config Config
+	err := FillE(&config)
+	if config.MaxBody != 10000000 || config.Cache != 2147483648 {
+		t.Errorf("FillE() = %+v; expected sizes in bytes", config)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "BUFFER" {
+		t.Errorf("FillE() error = %v; expected BUFFER to overflow", err)
+	}
+}
+
+func TestParseSignedSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"10MB", 10000000},
+		{"-10MB", -10000000},
+		{"-0.5GB", -500000000},
+		{"-9223372036854775808", math.MinInt64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := parseSignedSize(test.input)
+			if err != nil || result != test.expected {
+				t.Errorf("parseSignedSize(%s) = %d, %v; expected %d", test.input, result, err, test.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"9223372036854775808", "-9223372036854775809"} {
+		if _, err := parseSignedSize(input); err == nil {
+			t.Errorf("parseSignedSize(%q) returned no error", input)
+		}
+	}
+}
+
+func TestFillSignedFractionalSize(t *testing.T) {
+	type Config struct {
+		Heap  int64  `size:"true"`
+		Delta int    `size:"true"`
+		Limit uint64 `size:"true"`
+	}
+	env := map[string]string{"HEAP": "0.5GB", "DELTA": "-10MB", "LIMIT": "-1KB"}
+
+	var config Config
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(env)})
+	if config.Heap != 500000000 || config.Delta != -10000000 {
+		t.Errorf("FillWithOptions() = %+v; expected fractional and negative sizes in bytes", config)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Key != "LIMIT" || !strings.Contains(err.Error(), "negative size") {
+		t.Errorf("FillWithOptions() error = %v; expected a negative size error for LIMIT", err)
+	}
+}
@@ -0,0 +1,28 @@
+package lazyenv
+
+import "os"
+
+// Snapshot returns a copy of the current process environment, to be restored later with Restore.
+// It is meant for tests that change the environment, like the ones that call Fill:
+//
+//	snapshot := lazyenv.Snapshot()
+//	defer lazyenv.Restore(snapshot)
+func Snapshot() map[string]string {
+	return environMap(os.Environ())
+}
+
+// Restore sets the process environment back to snapshot, as returned by Snapshot.
+// The variables that were changed are set to their previous value,
+// and the ones that didn't exist when the snapshot was taken are unset.
+func Restore(snapshot map[string]string) {
+	for key := range environMap(os.Environ()) {
+		if _, ok := snapshot[key]; !ok {
+			os.Unsetenv(key)
+		}
+	}
+	for key, value := range snapshot {
+		if current, ok := os.LookupEnv(key); !ok || current != value {
+			os.Setenv(key, value)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package lazyenv
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	t.Setenv("LAZYENV_CHANGED", "before")
+	t.Setenv("LAZYENV_REMOVED", "before")
+
+	snapshot := Snapshot()
+	if snapshot["LAZYENV_CHANGED"] != "before" {
+		t.Fatalf("Snapshot() = %v; expected LAZYENV_CHANGED=before", snapshot)
+	}
+
+	os.Setenv("LAZYENV_CHANGED", "after")
+	os.Unsetenv("LAZYENV_REMOVED")
+	os.Setenv("LAZYENV_ADDED", "after")
+	Restore(snapshot)
+
+	if value := os.Getenv("LAZYENV_CHANGED"); value != "before" {
+		t.Errorf("LAZYENV_CHANGED = %q; expected %q", value, "before")
+	}
+	if value := os.Getenv("LAZYENV_REMOVED"); value != "before" {
+		t.Errorf("LAZYENV_REMOVED = %q; expected %q", value, "before")
+	}
+	if value, ok := os.LookupEnv("LAZYENV_ADDED"); ok {
+		t.Errorf("LAZYENV_ADDED = %q; expected it to be unset", value)
+	}
+}
@@ -0,0 +1,44 @@
+package lazyenv
+
+import (
+	"os"
+	"strings"
+	"text/template"
+)
+
+// isTemplate reports whether a default value is a text/template to execute instead of a literal value.
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// executeDefault executes the default value text as a text/template with a templateContext.
+func (f *filler) executeDefault(text string) (string, error) {
+	tmpl, err := template.New("default").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	if err := tmpl.Execute(&result, templateContext{f}); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// templateContext is the data of the templates of default values.
+type templateContext struct {
+	f *filler
+}
+
+// Env returns the value of the variable name, read with the lookup of the filler.
+// Like in interpolation, a variable that is not set gets the resolved value of the field filled from it, if any.
+func (c templateContext) Env(name string) string {
+	if value, ok := c.f.lookup(name); ok {
+		return value
+	}
+	return c.f.resolved[name]
+}
+
+// Hostname returns the host name reported by the kernel.
+func (c templateContext) Hostname() (string, error) {
+	return os.Hostname()
+}
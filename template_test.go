@@ -0,0 +1,52 @@
+package lazyenv
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFillTemplateDefault(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	var config struct {
+		Region  string
+		Node    string `default:"{{.Hostname}}-node"`
+		Queue   string `default:"{{.Env \"REGION\"}}-jobs"`
+		Cluster string `default:"{{.Env \"NODE\"}}.cluster"`
+		Plain   string `default:"plain {value}"`
+	}
+	env := map[string]string{"REGION": "eu"}
+	if err := FillWithOptions(&config, Options{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("FillWithOptions() error = %v", err)
+	}
+
+	expect := map[string]string{
+		"Node":    hostname + "-node",
+		"Queue":   "eu-jobs",
+		"Cluster": hostname + "-node.cluster",
+		"Plain":   "plain {value}",
+	}
+	got := map[string]string{"Node": config.Node, "Queue": config.Queue, "Cluster": config.Cluster, "Plain": config.Plain}
+	for name, value := range expect {
+		if got[name] != value {
+			t.Errorf("%s = %q; expected %q", name, got[name], value)
+		}
+	}
+}
+
+func TestFillTemplateDefaultError(t *testing.T) {
+	var config struct {
+		Node  string `default:"{{.Hostname"`
+		Queue string `default:"{{.Missing}}"`
+	}
+	err := FillWithOptions(&config, Options{Lookup: mapLookup(nil)})
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("FillWithOptions() error = %v; expected a parse and an execution error", err)
+	}
+}